@@ -18,9 +18,13 @@ import (
 
 type Message struct {
 	RequestID string `json:"request_id"`
+	SessionID string `json:"session_id,omitempty"`
 	Message   string `json:"message"`
 	Reply     string `json:"reply,omitempty"`
 	Error     string `json:"error,omitempty"`
+	// Debug asks the Chatbot for the answers the reply was chosen from.
+	Debug   bool            `json:"debug,omitempty"`
+	Answers json.RawMessage `json:"answers,omitempty"`
 }
 
 var (
@@ -95,7 +99,9 @@ func chatHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Message string `json:"message"`
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
+		Debug     bool   `json:"debug"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -104,11 +110,14 @@ func chatHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// requests without a session are answered in one the chatbot does not keep
 	requestID := uuid.New().String()
 
 	msg := Message{
 		RequestID: requestID,
+		SessionID: req.SessionID,
 		Message:   req.Message,
+		Debug:     req.Debug || r.URL.Query().Get("debug") == "true",
 	}
 
 	log.Printf("Sending message to Chatbot: %+v", msg)
@@ -123,32 +132,36 @@ func chatHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Received reply from Chatbot: %s", reply)
+	log.Printf("Received reply from Chatbot: %s", reply.Reply)
 
 	resp := struct {
-		Reply string `json:"reply"`
+		Reply     string          `json:"reply"`
+		SessionID string          `json:"session_id,omitempty"`
+		Debug     json.RawMessage `json:"debug,omitempty"`
 	}{
-		Reply: reply,
+		Reply:     reply.Reply,
+		SessionID: req.SessionID,
+		Debug:     reply.Answers,
 	}
 
 	json.NewEncoder(w).Encode(resp)
 }
 
-func sendMessageToChatbot(msg Message) (string, error) {
+func sendMessageToChatbot(msg Message) (Message, error) {
 	data, err := json.Marshal(msg)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal message: %v", err)
+		return Message{}, fmt.Errorf("failed to marshal message: %v", err)
 	}
 
 	ipcConnMutex.Lock()
 	if ipcConn == nil {
 		ipcConnMutex.Unlock()
-		return "", fmt.Errorf("not connected to Chatbot's IPC")
+		return Message{}, fmt.Errorf("not connected to Chatbot's IPC")
 	}
 	_, err = ipcConn.Write(append(data, '\n'))
 	ipcConnMutex.Unlock()
 	if err != nil {
-		return "", fmt.Errorf("failed to write to Chatbot's IPC: %v", err)
+		return Message{}, fmt.Errorf("failed to write to Chatbot's IPC: %v", err)
 	}
 
 	log.Printf("Message sent to Chatbot: %s", string(data))
@@ -156,11 +169,11 @@ func sendMessageToChatbot(msg Message) (string, error) {
 	ipcReaderMux.Lock()
 	defer ipcReaderMux.Unlock()
 	if ipcReader == nil {
-		return "", fmt.Errorf("ipcReader is not initialized")
+		return Message{}, fmt.Errorf("ipcReader is not initialized")
 	}
 	line, err := ipcReader.ReadBytes('\n')
 	if err != nil {
-		return "", fmt.Errorf("failed to read from Chatbot's IPC: %v", err)
+		return Message{}, fmt.Errorf("failed to read from Chatbot's IPC: %v", err)
 	}
 
 	log.Printf("Raw response received: %s", string(line))
@@ -168,14 +181,14 @@ func sendMessageToChatbot(msg Message) (string, error) {
 	var resp Message
 	err = json.Unmarshal(line, &resp)
 	if err != nil {
-		return "", fmt.Errorf("invalid JSON response from Chatbot: %v", err)
+		return Message{}, fmt.Errorf("invalid JSON response from Chatbot: %v", err)
 	}
 
 	if resp.Error != "" {
-		return "", fmt.Errorf(resp.Error)
+		return Message{}, fmt.Errorf(resp.Error)
 	}
 
-	return resp.Reply, nil
+	return resp, nil
 }
 
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
@@ -186,6 +199,8 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	sessionID := uuid.New().String()
+
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
@@ -202,6 +217,7 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		requestID := uuid.New().String()
 		msg := Message{
 			RequestID: requestID,
+			SessionID: sessionID,
 			Message:   clientMessage,
 		}
 
@@ -218,9 +234,9 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		log.Printf("Received WebSocket reply from Chatbot: %s", reply)
+		log.Printf("Received WebSocket reply from Chatbot: %s", reply.Reply)
 
-		err = conn.WriteMessage(websocket.TextMessage, []byte(reply))
+		err = conn.WriteMessage(websocket.TextMessage, []byte(reply.Reply))
 		if err != nil {
 			log.Println("WebSocket Write error:", err)
 			break
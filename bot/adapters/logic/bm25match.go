@@ -0,0 +1,183 @@
+package logic
+
+import (
+	"math"
+	"sort"
+
+	"golangChatBot/bot/adapters/storage"
+)
+
+const (
+	bm25MatchName = "BM25Match"
+
+	// the usual BM25 parameters, k1 saturates the term frequency and b
+	// weighs the normalization by the length of the key.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type (
+	bm25Match struct {
+		verbose bool
+		storage storage.IndexedStorage
+		tops    int
+	}
+
+	keyAndRelevance struct {
+		key       string
+		relevance float64
+		boost     float32
+	}
+)
+
+// NewBM25Match creates a logic adapter that ranks the stored questions by
+// BM25 over the inverted index of the storage. Rare terms shared with the
+// question count more than common ones, and the length of the stored
+// question matters less than with edit distances.
+func NewBM25Match(storage storage.IndexedStorage, tops int) LogicAdapter {
+	return &bm25Match{
+		storage: storage,
+		tops:    tops,
+	}
+}
+
+func (match *bm25Match) CanProcess(text string) bool {
+	return true
+}
+
+func (match *bm25Match) Process(text string, opts ...ProcessOption) []Answer {
+	if responses, ok := match.storage.Find(text); ok {
+		return match.processExactMatch(text, responses)
+	}
+
+	return match.processRelevanceMatch(text, buildProcessOptions(opts))
+}
+
+func (match *bm25Match) SetVerbose() {
+	match.verbose = true
+}
+
+func (match *bm25Match) processExactMatch(text string, responses map[string]int) []Answer {
+	response, _ := bestResponse(responses)
+	return []Answer{{
+		Content:    response,
+		Confidence: 1,
+		Question:   text,
+		Categories: match.storage.Categories(text),
+		Adapter:    bm25MatchName,
+	}}
+}
+
+// processRelevanceMatch scores the keys sharing terms with text. The
+// confidence is the relevance relative to an average length key holding all
+// indexed terms of text, times the share of the terms of text that are indexed.
+func (match *bm25Match) processRelevanceMatch(text string, options processOptions) []Answer {
+	index := match.storage.TermIndex(text)
+	terms := index.Terms(text)
+	documents := float64(index.Documents())
+	averageLength := index.AverageLength()
+	if len(terms) == 0 || documents == 0 || averageLength == 0 {
+		return nil
+	}
+
+	relevances := make(map[string]float64)
+	var ideal float64
+	var indexed int
+	for _, term := range terms {
+		frequency := float64(index.DocumentFrequency(term))
+		if frequency == 0 {
+			continue
+		}
+
+		indexed++
+		idf := math.Log(1 + (documents-frequency+0.5)/(frequency+0.5))
+		ideal += idf
+		for _, posting := range index.Postings(term) {
+			norm := 1 - bm25B + bm25B*float64(posting.Length)/averageLength
+			relevances[posting.Key] += idf * (bm25K1 + 1) / (1 + bm25K1*norm)
+		}
+	}
+	if len(relevances) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(relevances))
+	for key := range relevances {
+		keys = append(keys, key)
+	}
+	keys = options.narrow(keys, match.storage.Categories)
+	if match.verbose {
+		printMatches(keys)
+	}
+
+	ranked := make([]keyAndRelevance, 0, len(keys))
+	for _, key := range keys {
+		var boost float32
+		if options.scoped() {
+			boost = options.boostFor(match.storage.Categories(key))
+		}
+		ranked = append(ranked, keyAndRelevance{
+			key:       key,
+			relevance: relevances[key],
+			boost:     boost,
+		})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		scoreI := ranked[i].relevance/ideal + float64(ranked[i].boost)
+		scoreJ := ranked[j].relevance/ideal + float64(ranked[j].boost)
+		if scoreI != scoreJ {
+			return scoreI > scoreJ
+		}
+		return ranked[i].key < ranked[j].key
+	})
+
+	coverage := float64(indexed) / float64(len(terms))
+	answers := make([]Answer, 0, match.tops)
+	for _, each := range ranked {
+		if len(answers) >= match.tops {
+			break
+		}
+
+		responses, ok := match.storage.Find(each.key)
+		if !ok {
+			continue
+		}
+		response, ok := bestResponse(responses)
+		if !ok {
+			continue
+		}
+
+		confidence := float32(math.Min(1, each.relevance/ideal)*coverage) + each.boost
+		if confidence > 1 {
+			confidence = 1
+		}
+		answers = append(answers, Answer{
+			Content:    response,
+			Confidence: confidence,
+			Question:   each.key,
+			Categories: match.storage.Categories(each.key),
+			Adapter:    bm25MatchName,
+			Scores: &Scores{
+				BM25:          float32(each.relevance),
+				CategoryBoost: each.boost,
+				Final:         confidence,
+			},
+		})
+	}
+
+	return answers
+}
+
+// bestResponse returns the response given most often, false if there is none.
+func bestResponse(responses map[string]int) (string, bool) {
+	var best string
+	var maxCount int
+	for response, count := range responses {
+		if count > maxCount || (count == maxCount && response < best) {
+			best = response
+			maxCount = count
+		}
+	}
+
+	return best, maxCount > 0
+}
@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"golangChatBot/bot/adapters/storage"
+)
+
+// newBM25Storage returns a storage of questions about gateways, only one of
+// them about the firewall.
+func newBM25Storage(t *testing.T) (storage.IndexedStorage, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "model.gob")
+	store, err := storage.NewSeparatedMemoryStorage(path, storage.Config{Tokenizer: storage.TokenizerEnglish})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		question := fmt.Sprintf("How do I reset the gateway of building %d?", i)
+		store.Update(question, map[string]int{fmt.Sprintf("Press reset on gateway %d.", i): 1})
+		store.AddCategory(question, "gateway")
+	}
+	store.Update("How do I open a port in the firewall?", map[string]int{"In the firewall settings.": 2, "Ask the admin.": 1})
+	store.AddCategory("How do I open a port in the firewall?", "security")
+	store.Update("Which sensors can I connect?", map[string]int{"Any Modbus sensor.": 1})
+	store.BuildIndex()
+
+	return store, path
+}
+
+func TestBM25RareTermsRankFirst(t *testing.T) {
+	store, _ := newBM25Storage(t)
+
+	answers := NewBM25Match(store, 5).Process("firewall of the gateway")
+	if len(answers) < 2 {
+		t.Fatalf("got %d answers, want the gateway questions too", len(answers))
+	}
+	if answers[0].Question != "How do I open a port in the firewall?" {
+		t.Fatalf("got %q first, want the question sharing the rare term", answers[0].Question)
+	}
+	for _, answer := range answers[1:] {
+		if answer.Confidence > answers[0].Confidence {
+			t.Errorf("%q ranks below %q with a higher confidence", answer.Question, answers[0].Question)
+		}
+	}
+}
+
+func TestBM25ConfidenceBounds(t *testing.T) {
+	store, _ := newBM25Storage(t)
+	match := NewBM25Match(store, 10)
+
+	for _, test := range []struct {
+		text string
+		opts []ProcessOption
+	}{
+		{"firewall", nil},
+		{"reset gateway building 3", nil},
+		{"reset reset gateway gateway firewall port", nil},
+		{"sensors", nil},
+		{"unknown words only", nil},
+		{"reset the gateway", []ProcessOption{WithCategoryBoost(0.5, "gateway")}},
+		{"open the firewall", []ProcessOption{WithCategoryBoost(1, "security")}},
+		{"reset the gateway", []ProcessOption{WithCategoryFilter("security")}},
+	} {
+		for _, answer := range match.Process(test.text, test.opts...) {
+			if answer.Confidence < 0 || answer.Confidence > 1 {
+				t.Errorf("Process(%q) gives %q a confidence of %f", test.text, answer.Question, answer.Confidence)
+			}
+		}
+	}
+	if answers := match.Process("unknown words only"); len(answers) != 0 {
+		t.Errorf("Process(%q) = %+v, want no answers", "unknown words only", answers)
+	}
+}
+
+func TestBM25ExactMatch(t *testing.T) {
+	store, _ := newBM25Storage(t)
+
+	question := "How do I open a port in the firewall?"
+	answers := NewBM25Match(store, 5).Process(question)
+	if len(answers) != 1 {
+		t.Fatalf("got %d answers, want the stored question only", len(answers))
+	}
+	answer := answers[0]
+	if answer.Question != question || answer.Confidence != 1 ||
+		answer.Content != "In the firewall settings." || answer.Adapter != bm25MatchName {
+		t.Fatalf("got %+v", answer)
+	}
+	if len(answer.Categories) != 1 || answer.Categories[0] != "security" {
+		t.Fatalf("got the categories %q", answer.Categories)
+	}
+}
+
+func TestBM25AfterRestore(t *testing.T) {
+	store, path := newBM25Storage(t)
+	if err := store.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := storage.NewSeparatedMemoryStorage(path, storage.Config{Tokenizer: storage.TokenizerEnglish})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{"firewall of the gateway", "reset gateway building 3", "sensors"} {
+		index, restoredIndex := store.TermIndex(text), restored.TermIndex(text)
+		if index.Documents() != restoredIndex.Documents() || index.AverageLength() != restoredIndex.AverageLength() {
+			t.Errorf("%q: %d documents of length %f, restored %d of length %f", text,
+				index.Documents(), index.AverageLength(), restoredIndex.Documents(), restoredIndex.AverageLength())
+		}
+		for _, term := range index.Terms(text) {
+			if df, restoredDF := index.DocumentFrequency(term), restoredIndex.DocumentFrequency(term); df != restoredDF {
+				t.Errorf("DocumentFrequency(%q) = %d, restored %d", term, df, restoredDF)
+			}
+		}
+
+		answers := NewBM25Match(store, 5).Process(text)
+		restoredAnswers := NewBM25Match(restored, 5).Process(text)
+		if len(answers) != len(restoredAnswers) {
+			t.Fatalf("%q: %d answers, restored %d", text, len(answers), len(restoredAnswers))
+		}
+		for i := range answers {
+			if answers[i].Question != restoredAnswers[i].Question || answers[i].Confidence != restoredAnswers[i].Confidence {
+				t.Errorf("%q: answer %d is %q (%f), restored %q (%f)", text, i,
+					answers[i].Question, answers[i].Confidence, restoredAnswers[i].Question, restoredAnswers[i].Confidence)
+			}
+		}
+	}
+}
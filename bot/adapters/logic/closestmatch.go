@@ -14,6 +14,8 @@ import (
 const (
 	chunkSize     = 10000
 	topAnswerSize = 10
+
+	closestMatchName = "ClosestMatch"
 )
 
 type (
@@ -24,6 +26,8 @@ type (
 
 	questionAndScore struct {
 		question string
+		text     float32
+		boost    float32
 		score    float32
 	}
 
@@ -58,11 +62,11 @@ func (match *closestMatch) CanProcess(string) bool {
 	return true
 }
 
-func (match *closestMatch) Process(text string) []Answer {
+func (match *closestMatch) Process(text string, opts ...ProcessOption) []Answer {
 	if responses, ok := match.storage.Find(text); ok {
-		return match.processExactMatch(responses)
+		return match.processExactMatch(text, responses)
 	} else {
-		return match.processSimilarMatch(text)
+		return match.processSimilarMatch(text, buildProcessOptions(opts))
 	}
 }
 
@@ -70,7 +74,7 @@ func (match *closestMatch) SetVerbose() {
 	match.verbose = true
 }
 
-func (match *closestMatch) processExactMatch(responses map[string]int) []Answer {
+func (match *closestMatch) processExactMatch(text string, responses map[string]int) []Answer {
 	var top topOccurAnswers
 
 	for key, occurrence := range responses {
@@ -86,17 +90,21 @@ func (match *closestMatch) processExactMatch(responses map[string]int) []Answer
 		tops = len(top.answers)
 	}
 
+	categories := match.storage.Categories(text)
 	answers := make([]Answer, tops)
 	for i := 0; i < tops; i++ {
 		answers[i].Content = top.answers[i].answer
 		answers[i].Confidence = 1
+		answers[i].Question = text
+		answers[i].Categories = categories
+		answers[i].Adapter = closestMatchName
 	}
 
 	return answers
 }
 
-func (match *closestMatch) processSimilarMatch(text string) []Answer {
-	slice, err := mr.MapReduce(generator(match, text), mapper(match), reducer(match))
+func (match *closestMatch) processSimilarMatch(text string, options processOptions) []Answer {
+	slice, err := mr.MapReduce(generator(match, text, options), mapper(match, options), reducer(match))
 	if err != nil {
 		return nil
 	}
@@ -105,11 +113,19 @@ func (match *closestMatch) processSimilarMatch(text string) []Answer {
 	for _, each := range slice {
 		if each.score > 0 {
 			if responses, ok := match.storage.Find(each.question); ok {
-				matches := match.processExactMatch(responses)
+				matches := match.processExactMatch(each.question, responses)
 				if len(matches) > 0 {
 					answers = append(answers, Answer{
 						Content:    matches[0].Content,
 						Confidence: each.score,
+						Question:   each.question,
+						Categories: matches[0].Categories,
+						Adapter:    closestMatchName,
+						Scores: &Scores{
+							Text:          each.text,
+							CategoryBoost: each.boost,
+							Final:         each.score,
+						},
 					})
 				}
 			}
@@ -145,9 +161,9 @@ func (top *topOccurAnswers) put(answer string, occurrence int) {
 	}
 }
 
-func generator(match *closestMatch, text string) mr.GenerateFunc[sourceAndTargets] {
+func generator(match *closestMatch, text string, options processOptions) mr.GenerateFunc[sourceAndTargets] {
 	return func(source chan<- sourceAndTargets) {
-		keys := match.storage.Search(text)
+		keys := options.narrow(match.storage.Search(text), match.storage.Categories)
 		if match.verbose {
 			printMatches(keys)
 		}
@@ -162,14 +178,24 @@ func generator(match *closestMatch, text string) mr.GenerateFunc[sourceAndTarget
 	}
 }
 
-func mapper(match *closestMatch) mr.MapperFunc[sourceAndTargets, *topScoreQuestions] {
+func mapper(match *closestMatch, options processOptions) mr.MapperFunc[sourceAndTargets, *topScoreQuestions] {
 	return func(pair sourceAndTargets, writer mr.Writer[*topScoreQuestions], cancel func(error)) {
 		tops := newTopScoreQuestions(match.tops)
 		for i := range pair.targets {
-			score := nlp.SimilarityForStrings(pair.source, pair.targets[i])
+			var boost float32
+			if options.scoped() {
+				boost = options.boostFor(match.storage.Categories(pair.targets[i]))
+			}
+			// targets that can't beat the lowest top score are given up early
+			similarity, ok := nlp.SimilarityForStringsAtLeast(pair.source, pair.targets[i], tops.lowest()-boost)
+			if !ok {
+				continue
+			}
 			tops.add(questionAndScore{
 				question: pair.targets[i],
-				score:    score,
+				text:     similarity,
+				boost:    boost,
+				score:    similarity + boost,
 			})
 		}
 
@@ -227,8 +253,21 @@ func newTopScoreQuestions(n int) *topScoreQuestions {
 	}
 }
 
+// lowest returns the lowest score in the tops, a question needs a higher one
+// to get in.
+func (tq *topScoreQuestions) lowest() float32 {
+	var score float32 = math.MaxFloat32
+	for _, each := range tq.questions {
+		if each.score < score {
+			score = each.score
+		}
+	}
+
+	return score
+}
+
 func (tq *topScoreQuestions) add(q questionAndScore) {
-	var score float32 = 1
+	var score float32 = math.MaxFloat32
 	var index int
 	for i, each := range tq.questions {
 		if each.score < score {
@@ -1,15 +1,87 @@
 package logic
 
-type comboMatch struct {
-	matches []LogicAdapter
-}
+import (
+	"sort"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/mr"
+)
+
+const (
+	// ComboFirst returns the answers of the first adapter that can process
+	// the question.
+	ComboFirst ComboMode = iota
+	// ComboMerge runs the adapters concurrently, merges the answers they have
+	// in common and reranks them by their weighted scores.
+	ComboMerge
+	// ComboFirstConfident returns the answers of the first adapter whose best
+	// answer reaches the threshold.
+	ComboFirstConfident
+)
+
+type (
+	// ComboMode tells how a combo match combines its adapters.
+	ComboMode int
+
+	// WeightedAdapter is an adapter of a merging combo match, a Weight of 0
+	// counts as 1.
+	WeightedAdapter struct {
+		Adapter LogicAdapter
+		Weight  float32
+	}
+
+	comboMatch struct {
+		mode      ComboMode
+		matches   []LogicAdapter
+		weights   []float32
+		threshold float32
+		tops      int
+	}
+
+	mergedAnswer struct {
+		answer Answer
+		score  float32
+		best   float32
+		names  []string
+	}
+)
 
 func NewComboMatch(matches ...LogicAdapter) LogicAdapter {
 	return &comboMatch{
+		mode:    ComboFirst,
 		matches: matches,
 	}
 }
 
+// NewMergingComboMatch creates a combo match in ComboMerge mode that returns
+// at most tops answers, 0 means all of them.
+func NewMergingComboMatch(tops int, adapters ...WeightedAdapter) LogicAdapter {
+	match := &comboMatch{
+		mode: ComboMerge,
+		tops: tops,
+	}
+	for _, each := range adapters {
+		weight := each.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		match.matches = append(match.matches, each.Adapter)
+		match.weights = append(match.weights, weight)
+	}
+
+	return match
+}
+
+// NewFirstConfidentComboMatch creates a combo match in ComboFirstConfident
+// mode. If no adapter reaches threshold, the answers with the best score win.
+func NewFirstConfidentComboMatch(threshold float32, matches ...LogicAdapter) LogicAdapter {
+	return &comboMatch{
+		mode:      ComboFirstConfident,
+		matches:   matches,
+		threshold: threshold,
+	}
+}
+
 func (match *comboMatch) CanProcess(question string) bool {
 	for _, each := range match.matches {
 		if each.CanProcess(question) {
@@ -19,10 +91,17 @@ func (match *comboMatch) CanProcess(question string) bool {
 	return false
 }
 
-func (match *comboMatch) Process(question string) []Answer {
+func (match *comboMatch) Process(question string, opts ...ProcessOption) []Answer {
+	switch match.mode {
+	case ComboMerge:
+		return match.processMerge(question, opts)
+	case ComboFirstConfident:
+		return match.processFirstConfident(question, opts)
+	}
+
 	for _, each := range match.matches {
 		if each.CanProcess(question) {
-			return each.Process(question)
+			return each.Process(question, opts...)
 		}
 	}
 	return nil
@@ -33,3 +112,125 @@ func (match *comboMatch) SetVerbose() {
 		each.SetVerbose()
 	}
 }
+
+func (match *comboMatch) processFirstConfident(question string, opts []ProcessOption) []Answer {
+	var best []Answer
+	for _, each := range match.matches {
+		if !each.CanProcess(question) {
+			continue
+		}
+
+		answers := each.Process(question, opts...)
+		if len(answers) == 0 {
+			continue
+		}
+		if answers[0].Confidence >= match.threshold {
+			return answers
+		}
+		if len(best) == 0 || answers[0].Confidence > best[0].Confidence {
+			best = answers
+		}
+	}
+
+	return best
+}
+
+// processMerge runs the adapters concurrently. The score of an answer is the
+// weighted mean of the confidences the adapters gave it, each divided by the
+// best confidence of its adapter so that adapters scoring on other scales,
+// like BM25, weigh as much as the others, and answers found by several
+// adapters rank above the ones found by one.
+func (match *comboMatch) processMerge(question string, opts []ProcessOption) []Answer {
+	results := make([][]Answer, len(match.matches))
+	fns := make([]func(), 0, len(match.matches))
+	for i, each := range match.matches {
+		i, each := i, each
+		if !each.CanProcess(question) {
+			continue
+		}
+		fns = append(fns, func() {
+			results[i] = each.Process(question, opts...)
+		})
+	}
+	mr.FinishVoid(fns...)
+
+	var totalWeight float32
+	for _, weight := range match.weights {
+		totalWeight += weight
+	}
+
+	merged := make(map[string]*mergedAnswer)
+	var order []string
+	for i, answers := range results {
+		confidences := normalize(answers)
+
+		// an adapter may return the same content more than once, count its best
+		seen := make(map[string]bool)
+		for j, answer := range answers {
+			if seen[answer.Content] {
+				continue
+			}
+			seen[answer.Content] = true
+
+			confidence := confidences[j]
+			entry, ok := merged[answer.Content]
+			if !ok {
+				entry = &mergedAnswer{answer: answer}
+				merged[answer.Content] = entry
+				order = append(order, answer.Content)
+			}
+			entry.score += match.weights[i] * confidence / totalWeight
+			entry.names = append(entry.names, answer.Adapter)
+			if confidence > entry.best {
+				entry.best = confidence
+				entry.answer = answer
+			}
+		}
+	}
+
+	answers := make([]Answer, 0, len(order))
+	for _, content := range order {
+		entry := merged[content]
+		answer := entry.answer
+		answer.Confidence = entry.score
+		answer.Adapter = strings.Join(entry.names, "+")
+		if answer.Scores != nil {
+			scores := *answer.Scores
+			scores.Final = entry.score
+			answer.Scores = &scores
+		}
+		answers = append(answers, answer)
+	}
+
+	sort.SliceStable(answers, func(i, j int) bool {
+		return answers[i].Confidence > answers[j].Confidence
+	})
+	if match.tops > 0 && len(answers) > match.tops {
+		answers = answers[:match.tops]
+	}
+
+	return answers
+}
+
+// normalize divides the confidences of the answers of an adapter by the best
+// of them, negative confidences count as 0.
+func normalize(answers []Answer) []float32 {
+	var best float32
+	for _, answer := range answers {
+		if answer.Confidence > best {
+			best = answer.Confidence
+		}
+	}
+
+	confidences := make([]float32, len(answers))
+	if best <= 0 {
+		return confidences
+	}
+	for i, answer := range answers {
+		if answer.Confidence > 0 {
+			confidences[i] = answer.Confidence / best
+		}
+	}
+
+	return confidences
+}
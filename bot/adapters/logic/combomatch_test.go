@@ -0,0 +1,85 @@
+package logic
+
+import "testing"
+
+// fixedAdapter returns the same answers to every question.
+type fixedAdapter struct {
+	answers []Answer
+}
+
+func (adapter *fixedAdapter) CanProcess(string) bool {
+	return true
+}
+
+func (adapter *fixedAdapter) Process(string, ...ProcessOption) []Answer {
+	return adapter.answers
+}
+
+func (adapter *fixedAdapter) SetVerbose() {
+}
+
+func TestMergeNormalizesScores(t *testing.T) {
+	// BM25 scores are unbounded, the best of them must not outweigh the
+	// confidences of the other adapter
+	bm25 := &fixedAdapter{answers: []Answer{
+		{Content: "a", Confidence: 12, Adapter: "bm25"},
+		{Content: "b", Confidence: 9, Adapter: "bm25"},
+		{Content: "c", Confidence: 3, Adapter: "bm25"},
+	}}
+	topic := &fixedAdapter{answers: []Answer{
+		{Content: "b", Confidence: 0.8, Adapter: "topic"},
+		{Content: "c", Confidence: 0.4, Adapter: "topic"},
+	}}
+
+	answers := NewMergingComboMatch(0,
+		WeightedAdapter{Adapter: bm25},
+		WeightedAdapter{Adapter: topic},
+	).Process("question")
+
+	want := map[string]float32{
+		"a": (1 + 0) / 2.0,
+		"b": (0.75 + 1) / 2.0,
+		"c": (0.25 + 0.5) / 2.0,
+	}
+	if len(answers) != len(want) {
+		t.Fatalf("got %d answers, want %d", len(answers), len(want))
+	}
+	if answers[0].Content != "b" {
+		t.Fatalf("got %q first, want the answer both adapters rank high", answers[0].Content)
+	}
+	for _, answer := range answers {
+		if diff := answer.Confidence - want[answer.Content]; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("confidence of %q = %f, want %f", answer.Content, answer.Confidence, want[answer.Content])
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		confidences []float32
+		want        []float32
+	}{
+		{nil, []float32{}},
+		{[]float32{0.5, 0.25}, []float32{1, 0.5}},
+		{[]float32{20, 5, -1}, []float32{1, 0.25, 0}},
+		{[]float32{0, -2}, []float32{0, 0}},
+	}
+
+	for _, test := range tests {
+		answers := make([]Answer, len(test.confidences))
+		for i, confidence := range test.confidences {
+			answers[i].Confidence = confidence
+		}
+
+		got := normalize(answers)
+		if len(got) != len(test.want) {
+			t.Fatalf("normalize(%v) = %v, want %v", test.confidences, got, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("normalize(%v) = %v, want %v", test.confidences, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -1,14 +1,191 @@
 package logic
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
 type (
 	Answer struct {
-		Content    string
-		Confidence float32
+		Content    string  `json:"content"`
+		Confidence float32 `json:"confidence"`
+		// Question is the stored question the answer belongs to.
+		Question string `json:"question,omitempty"`
+		// Categories are the corpus categories Question was trained under.
+		Categories []string `json:"categories,omitempty"`
+		// Adapter names the logic adapter that produced the answer.
+		Adapter string `json:"adapter,omitempty"`
+		// Scores breaks down the score of a fuzzy match, nil on exact matches.
+		Scores *Scores `json:"scores,omitempty"`
+	}
+
+	// Scores are the components an adapter combined into the score of a match.
+	// Components an adapter doesn't compute stay zero.
+	Scores struct {
+		Text          float32 `json:"text"`
+		Topic         float32 `json:"topic"`
+		LengthRatio   float32 `json:"length_ratio"`
+		TopicRatio    float32 `json:"topic_ratio"`
+		BM25          float32 `json:"bm25,omitempty"`
+		CategoryBoost float32 `json:"category_boost"`
+		Final         float32 `json:"final"`
 	}
 
 	LogicAdapter interface {
 		CanProcess(string) bool
-		Process(string) []Answer
+		Process(string, ...ProcessOption) []Answer
 		SetVerbose()
 	}
+
+	// ProcessOption customizes a single call to LogicAdapter.Process.
+	ProcessOption func(*processOptions)
+
+	processOptions struct {
+		categories map[string]bool
+		filter     bool
+		boost      float32
+	}
 )
+
+// WithCategoryFilter only keeps candidates stored under one of the given
+// categories. If no candidate belongs to any of them, nothing is filtered.
+func WithCategoryFilter(categories ...string) ProcessOption {
+	return func(opts *processOptions) {
+		opts.categories = categorySet(categories)
+		opts.filter = true
+	}
+}
+
+// WithCategoryBoost adds boost to the score of candidates stored under one of
+// the given categories.
+func WithCategoryBoost(boost float32, categories ...string) ProcessOption {
+	return func(opts *processOptions) {
+		opts.categories = categorySet(categories)
+		opts.boost = boost
+	}
+}
+
+// Explain describes where the answer came from and how it was scored.
+func (answer Answer) Explain() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Adapter: %s\n", answer.Adapter)
+	fmt.Fprintf(&builder, "Matched question: %s\n", answer.Question)
+	if len(answer.Categories) > 0 {
+		fmt.Fprintf(&builder, "Categories: %s\n", strings.Join(answer.Categories, ", "))
+	}
+	fmt.Fprintf(&builder, "Confidence: %.3f\n", answer.Confidence)
+
+	if answer.Scores == nil {
+		builder.WriteString("Exact match\n")
+		return builder.String()
+	}
+
+	scores := answer.Scores
+	if scores.BM25 != 0 {
+		fmt.Fprintf(&builder, "Scores: bm25 %.3f", scores.BM25)
+	} else {
+		fmt.Fprintf(&builder, "Scores: text %.3f, topic %.3f, length ratio %.3f, topic ratio %.3f",
+			scores.Text, scores.Topic, scores.LengthRatio, scores.TopicRatio)
+	}
+	if scores.CategoryBoost != 0 {
+		fmt.Fprintf(&builder, ", category boost %.3f", scores.CategoryBoost)
+	}
+	fmt.Fprintf(&builder, ", final %.3f\n", scores.Final)
+
+	return builder.String()
+}
+
+func buildProcessOptions(opts []ProcessOption) processOptions {
+	var options processOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+func categorySet(categories []string) map[string]bool {
+	set := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		set[strings.ToLower(category)] = true
+	}
+
+	return set
+}
+
+func (opts processOptions) scoped() bool {
+	return len(opts.categories) > 0
+}
+
+// inScope tells whether any of the given categories is one of the requested,
+// or names one of them among its words, the keywords of a session like
+// "periMICA" being shorter than categories like "periMICA Overview".
+func (opts processOptions) inScope(categories []string) bool {
+	for _, category := range categories {
+		category = strings.ToLower(category)
+		if opts.categories[category] {
+			return true
+		}
+		words := categoryWords(category)
+		for requested := range opts.categories {
+			if containsWords(words, categoryWords(requested)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// categoryWords splits a lowercased category into its words.
+func categoryWords(category string) []string {
+	return strings.FieldsFunc(category, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWords tells whether words holds the sequence of requested words.
+func containsWords(words, requested []string) bool {
+	if len(requested) == 0 {
+		return false
+	}
+
+	for i := 0; i+len(requested) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(requested)], requested) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// narrow drops the questions out of scope when filtering, unless that would
+// drop all of them.
+func (opts processOptions) narrow(questions []string, categoriesOf func(string) []string) []string {
+	if !opts.scoped() || !opts.filter {
+		return questions
+	}
+
+	var result []string
+	for _, question := range questions {
+		if opts.inScope(categoriesOf(question)) {
+			result = append(result, question)
+		}
+	}
+	if len(result) == 0 {
+		return questions
+	}
+
+	return result
+}
+
+// boostFor returns the category boost to add to the score of a candidate.
+func (opts processOptions) boostFor(categories []string) float32 {
+	if opts.boost != 0 && opts.inScope(categories) {
+		return opts.boost
+	}
+
+	return 0
+}
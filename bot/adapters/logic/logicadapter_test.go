@@ -0,0 +1,51 @@
+package logic
+
+import "testing"
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		requested  []string
+		categories []string
+		inScope    bool
+	}{
+		{[]string{"greetings"}, []string{"greetings"}, true},
+		{[]string{"Perinet"}, []string{"perinet"}, true},
+		{[]string{"periMICA"}, []string{"periMICA Overview and Functions"}, true},
+		{[]string{"perimica overview"}, []string{"periMICA Overview and Functions"}, true},
+		{[]string{"periNODE 0-10V"}, []string{"periNODE 0-10V Sensors"}, true},
+		{[]string{"periMICA"}, []string{"perinet", "greetings"}, false},
+		{[]string{"overview functions"}, []string{"periMICA Overview and Functions"}, false},
+		{[]string{"peri"}, []string{"periMICA General Information"}, false},
+		{[]string{"perinet"}, []string{"perinetGMBH"}, false},
+		{[]string{"periMICA"}, nil, false},
+	}
+
+	for _, test := range tests {
+		options := buildProcessOptions([]ProcessOption{WithCategoryFilter(test.requested...)})
+		if got := options.inScope(test.categories); got != test.inScope {
+			t.Errorf("inScope(%q) with %q requested = %t, want %t", test.categories, test.requested, got, test.inScope)
+		}
+	}
+}
+
+func TestNarrowKeepsKeywordCategories(t *testing.T) {
+	categories := map[string][]string{
+		"What is periMICA?":  {"periMICA Overview and Functions"},
+		"What is Perinet?":   {"Perinet"},
+		"How are you doing?": {"greetings"},
+	}
+	categoriesOf := func(question string) []string {
+		return categories[question]
+	}
+
+	options := buildProcessOptions([]ProcessOption{WithCategoryFilter("periMICA")})
+	got := options.narrow([]string{"What is periMICA?", "What is Perinet?", "How are you doing?"}, categoriesOf)
+	if len(got) != 1 || got[0] != "What is periMICA?" {
+		t.Fatalf("narrow kept %q, want the periMICA question only", got)
+	}
+
+	boost := buildProcessOptions([]ProcessOption{WithCategoryBoost(0.1, "periMICA")})
+	if got := boost.boostFor(categories["What is periMICA?"]); got != 0.1 {
+		t.Fatalf("boostFor = %f, want 0.1", got)
+	}
+}
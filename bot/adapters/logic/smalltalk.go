@@ -0,0 +1,170 @@
+package logic
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	smallTalkName = "SmallTalk"
+
+	// smallTalkWord is replaced by the word in the one word replies.
+	smallTalkWord = "{word}"
+)
+
+var (
+	defaultGreetings       = []string{"hi", "hello", "hey", "greetings", "sup", "yo"}
+	defaultGreetingReplies = []string{"Hi there! Please ask me more about the Perinet products."}
+	defaultOneWordReplies  = []string{smallTalkWord + ", can you provide more context to this?"}
+)
+
+type (
+	// SmallTalkResponses are the replies of SmallTalk, read from a YAML file.
+	// One of the variants is picked per question, the same one for the same
+	// question.
+	SmallTalkResponses struct {
+		Greetings []string `yaml:"greetings"`
+		// OneWord are the replies to one word questions, {word} is replaced
+		// by the word.
+		OneWord []string `yaml:"one_word"`
+	}
+
+	smallTalk struct {
+		verbose   bool
+		greetings map[string]bool
+		responses SmallTalkResponses
+	}
+)
+
+// NewSmallTalk creates a logic adapter that answers greetings and asks for
+// more context on one word questions, the questions the stored answers don't
+// fit. greetingsFile holds a greeting per line and responsesFile the
+// replies, empty file names mean the built-in ones. It goes first in a
+// ComboMatch, so that the other adapters get the rest of the questions.
+func NewSmallTalk(greetingsFile, responsesFile string) (LogicAdapter, error) {
+	greetings := defaultGreetings
+	if len(greetingsFile) > 0 {
+		var err error
+		if greetings, err = loadGreetings(greetingsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	responses := SmallTalkResponses{
+		Greetings: defaultGreetingReplies,
+		OneWord:   defaultOneWordReplies,
+	}
+	if len(responsesFile) > 0 {
+		var err error
+		if responses, err = loadSmallTalkResponses(responsesFile); err != nil {
+			return nil, err
+		}
+	}
+
+	match := &smallTalk{
+		greetings: make(map[string]bool, len(greetings)),
+		responses: responses,
+	}
+	for _, greeting := range greetings {
+		match.greetings[normalizeSmallTalk(greeting)] = true
+	}
+
+	return match, nil
+}
+
+func (match *smallTalk) CanProcess(text string) bool {
+	normalized := normalizeSmallTalk(text)
+	return match.greetings[normalized] || len(strings.Fields(normalized)) == 1
+}
+
+func (match *smallTalk) Process(text string, _ ...ProcessOption) []Answer {
+	normalized := normalizeSmallTalk(text)
+	var content string
+	if match.greetings[normalized] {
+		content = pickReply(match.responses.Greetings, normalized)
+	} else if len(strings.Fields(normalized)) == 1 {
+		word := strings.TrimFunc(strings.TrimSpace(text), unicode.IsPunct)
+		content = strings.ReplaceAll(pickReply(match.responses.OneWord, normalized), smallTalkWord, word)
+	} else {
+		return nil
+	}
+
+	if match.verbose {
+		fmt.Printf("small talk: %q -> %q\n", text, content)
+	}
+
+	return []Answer{{
+		Content:    content,
+		Confidence: 1,
+		Question:   text,
+		Adapter:    smallTalkName,
+	}}
+}
+
+func (match *smallTalk) SetVerbose() {
+	match.verbose = true
+}
+
+// loadGreetings reads the greetings, one per line.
+func loadGreetings(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("small talk greetings: %w", err)
+	}
+	defer file.Close()
+
+	var greetings []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			greetings = append(greetings, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("small talk greetings %s: %w", filename, err)
+	}
+	if len(greetings) == 0 {
+		return nil, fmt.Errorf("small talk greetings %s: no greetings", filename)
+	}
+
+	return greetings, nil
+}
+
+func loadSmallTalkResponses(filename string) (SmallTalkResponses, error) {
+	var responses SmallTalkResponses
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return responses, fmt.Errorf("small talk responses: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &responses); err != nil {
+		return responses, fmt.Errorf("small talk responses %s: %w", filename, err)
+	}
+	if len(responses.Greetings) == 0 || len(responses.OneWord) == 0 {
+		return responses, fmt.Errorf("small talk responses %s: greetings and one_word need replies", filename)
+	}
+
+	return responses, nil
+}
+
+// normalizeSmallTalk lowercases text and trims the spaces and punctuation
+// around it, so that "Hello!" is a greeting.
+func normalizeSmallTalk(text string) string {
+	return strings.TrimFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+}
+
+// pickReply picks one of the replies by the hash of question, so that every
+// front end gives the same reply to the same question.
+func pickReply(replies []string, question string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(question))
+	return replies[hash.Sum32()%uint32(len(replies))]
+}
@@ -0,0 +1,128 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testSmallTalkFile = "../../../cli/etc/small_talk.yaml"
+
+func TestSmallTalkDetection(t *testing.T) {
+	match, err := NewSmallTalk("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text    string
+		process bool
+		reply   string
+	}{
+		{"hi", true, defaultGreetingReplies[0]},
+		{"  Hello! ", true, defaultGreetingReplies[0]},
+		{"HEY...", true, defaultGreetingReplies[0]},
+		{"periMICA?", true, "periMICA, can you provide more context to this?"},
+		{"  SCADA ", true, "SCADA, can you provide more context to this?"},
+		{"0-10V", true, "0-10V, can you provide more context to this?"},
+		{"hi there", false, ""},
+		{"what is the periMICA?", false, ""},
+		{"", false, ""},
+		{"?!", false, ""},
+	}
+	for _, test := range tests {
+		if got := match.CanProcess(test.text); got != test.process {
+			t.Errorf("CanProcess(%q) = %t, want %t", test.text, got, test.process)
+		}
+
+		answers := match.Process(test.text)
+		if !test.process {
+			if len(answers) > 0 {
+				t.Errorf("Process(%q) = %+v, want no answers", test.text, answers)
+			}
+			continue
+		}
+		if len(answers) != 1 || answers[0].Content != test.reply || answers[0].Confidence != 1 ||
+			answers[0].Question != test.text || answers[0].Adapter != smallTalkName {
+			t.Errorf("Process(%q) = %+v, want %q", test.text, answers, test.reply)
+		}
+	}
+}
+
+func TestSmallTalkVariants(t *testing.T) {
+	match, err := NewSmallTalk("", testSmallTalkFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, err := loadSmallTalkResponses(testSmallTalkFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	picked := make(map[string]bool)
+	for _, text := range []string{"hi", "hello", "hey", "greetings", "sup", "yo"} {
+		reply := match.Process(text)[0].Content
+		if !slices.Contains(responses.Greetings, reply) {
+			t.Fatalf("Process(%q) = %q, not a greeting reply", text, reply)
+		}
+		picked[reply] = true
+
+		// the same question gets the same variant, whatever its case and punctuation
+		for _, again := range []string{text, strings.ToUpper(text) + "!"} {
+			if got := match.Process(again)[0].Content; got != reply {
+				t.Errorf("Process(%q) = %q, Process(%q) = %q", text, reply, again, got)
+			}
+		}
+	}
+	if len(picked) < 2 {
+		t.Errorf("the greetings all get the same reply: %v", picked)
+	}
+
+	for _, word := range []string{"periMICA", "periNODE", "SCADA", "gateway", "firmware"} {
+		reply := match.Process(word + "?")[0].Content
+		if strings.Contains(reply, smallTalkWord) || !strings.Contains(reply, word) {
+			t.Errorf("Process(%q) = %q, want %q in place of %s", word+"?", reply, word, smallTalkWord)
+		}
+		if got := match.Process(word + "?")[0].Content; got != reply {
+			t.Errorf("Process(%q) = %q, then %q", word+"?", reply, got)
+		}
+	}
+}
+
+func TestSmallTalkFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	greetings := write("greetings.txt", "# greetings\nmoin\n\nServus\n")
+	match, err := NewSmallTalk(greetings, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answers := match.Process("servus!"); len(answers) != 1 || answers[0].Content != defaultGreetingReplies[0] {
+		t.Fatalf("Process(%q) = %+v, want a greeting reply", "servus!", answers)
+	}
+	if match.CanProcess("hello there") {
+		t.Fatal("the built-in greetings are still used")
+	}
+
+	for name, files := range map[string][2]string{
+		"missing greetings":  {filepath.Join(dir, "missing.txt"), ""},
+		"empty greetings":    {write("empty.txt", "# none\n\n"), ""},
+		"missing responses":  {"", filepath.Join(dir, "missing.yaml")},
+		"malformed response": {"", write("malformed.yaml", "greetings: [\"Hi\"\none_word: {\n")},
+		"missing replies":    {"", write("partial.yaml", "greetings:\n  - \"Hi!\"\n")},
+		"wrong types":        {"", write("types.yaml", "greetings: hello\none_word: 3\n")},
+	} {
+		if _, err := NewSmallTalk(files[0], files[1]); err == nil {
+			t.Errorf("%s: NewSmallTalk(%q, %q) returned no error", name, files[0], files[1])
+		}
+	}
+}
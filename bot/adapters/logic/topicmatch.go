@@ -7,29 +7,67 @@ import (
 	"strings"
 )
 
+const topicMatchName = "TopicMatch"
+
 // TopicScore holds the scoring information for a potential match
 type TopicScore struct {
-	Question   string
-	TextScore  float32
-	TopicScore float32
-	FinalScore float32
+	Question      string
+	TextScore     float32
+	TopicScore    float32
+	LengthRatio   float32
+	TopicRatio    float32
+	CategoryBoost float32
+	FinalScore    float32
 }
 
 // TopicMatch implements the LogicAdapter interface with topic-based matching
 type TopicMatch struct {
-	verbose   bool
-	storage   storage.StorageAdapter
-	tops      int
-	stopWords map[string]bool
+	verbose       bool
+	storage       storage.StorageAdapter
+	tops          int
+	stopWords     map[string]bool
+	weights       topicWeights
+	minWordLength int
+}
+
+// topicCounts holds the number of distinct topics of a question shared with
+// the input, of its distinct topics and of its topics with the repeated ones
+type topicCounts struct {
+	shared   int
+	distinct int
+	total    int
 }
 
-// NewTopicMatch creates a new TopicMatch instance
+// NewTopicMatch creates a new TopicMatch instance with the default config
 func NewTopicMatch(storage storage.StorageAdapter, tops int) LogicAdapter {
-	return &TopicMatch{
-		storage:   storage,
-		tops:      tops,
-		stopWords: initializeStopWords(),
+	match, _ := NewTopicMatchWithConfig(storage, tops, DefaultTopicMatchConfig())
+	return match
+}
+
+// NewTopicMatchWithConfig creates a new TopicMatch instance scoring as config says
+func NewTopicMatchWithConfig(storage storage.StorageAdapter, tops int, config TopicMatchConfig) (LogicAdapter, error) {
+	weights, err := config.weights()
+	if err != nil {
+		return nil, err
 	}
+
+	stopWords, err := config.stopWords()
+	if err != nil {
+		return nil, err
+	}
+
+	minWordLength := config.MinWordLength
+	if minWordLength <= 0 {
+		minWordLength = defaultMinWordLength
+	}
+
+	return &TopicMatch{
+		storage:       storage,
+		tops:          tops,
+		stopWords:     stopWords,
+		weights:       weights,
+		minWordLength: minWordLength,
+	}, nil
 }
 
 // CanProcess implements LogicAdapter interface
@@ -43,16 +81,17 @@ func (match *TopicMatch) SetVerbose() {
 }
 
 // Process implements LogicAdapter interface
-func (match *TopicMatch) Process(text string) []Answer {
+func (match *TopicMatch) Process(text string, opts ...ProcessOption) []Answer {
 	if responses, ok := match.storage.Find(text); ok {
-		return match.processExactMatch(responses)
+		return match.processExactMatch(text, responses)
 	}
-	return match.processTopicMatch(text)
+	return match.processTopicMatch(text, buildProcessOptions(opts))
 }
 
 // processExactMatch handles exact matches found in storage
-func (match *TopicMatch) processExactMatch(responses map[string]int) []Answer {
+func (match *TopicMatch) processExactMatch(text string, responses map[string]int) []Answer {
 	var answers []Answer
+	categories := match.storage.Categories(text)
 
 	// Find max count for normalization
 	maxCount := 0
@@ -68,6 +107,9 @@ func (match *TopicMatch) processExactMatch(responses map[string]int) []Answer {
 		answers = append(answers, Answer{
 			Content:    response,
 			Confidence: normalizedConfidence,
+			Question:   text,
+			Categories: categories,
+			Adapter:    topicMatchName,
 		})
 	}
 
@@ -85,36 +127,63 @@ func (match *TopicMatch) processExactMatch(responses map[string]int) []Answer {
 }
 
 // processTopicMatch handles fuzzy matching based on topic similarity
-func (match *TopicMatch) processTopicMatch(text string) []Answer {
+func (match *TopicMatch) processTopicMatch(text string, options processOptions) []Answer {
 	// Extract topics from input
 	inputTopics := match.extractTopics(text)
+	inputSet := make(map[string]bool, len(inputTopics))
+	for _, topic := range inputTopics {
+		inputSet[topic] = true
+	}
+
+	// Storages that keep the token sets of their keys spare splitting every candidate
+	tokenSets, _ := match.storage.(storage.TokenSetIndex)
 
-	// Get candidate matches
-	candidates := match.storage.Search(text)
+	// Get candidate matches, narrowed to the requested categories if any
+	candidates := options.narrow(match.storage.Search(text), match.storage.Categories)
 
 	// Score and rank candidates
 	scores := make([]TopicScore, 0)
+	weights := match.weights
 	for _, candidate := range candidates {
-		candidateTopics := match.extractTopics(candidate)
+		candidateTopics := match.countTopics(inputSet, match.candidateTokens(tokenSets, candidate))
+		candidateCount := candidateTopics.total
 
-		textScore := nlp.SimilarityForStrings(text, candidate)
-		topicScore := match.calculateTopicSimilarity(inputTopics, candidateTopics)
-
-		// Calculate weighted scores based on different factors
-		lengthRatio := float32(min(len(text), len(candidate))) / float32(max(len(text), len(candidate)))
-		topicRatio := float32(len(inputTopics)) / float32(max(len(inputTopics), len(candidateTopics)))
+		// Calculate the enabled scores, disabled ones have no weight
+		var textScore, topicScore, lengthRatio, topicRatio float32
+		if weights.text > 0 {
+			textScore = nlp.SimilarityForStrings(text, candidate)
+		}
+		if weights.topic > 0 {
+			topicScore = match.calculateTopicSimilarity(inputSet, candidateTopics)
+		}
+		if weights.length > 0 {
+			lengthRatio = float32(min(len(text), len(candidate))) / float32(max(len(text), len(candidate)))
+		}
+		if weights.topicCount > 0 && max(len(inputTopics), candidateCount) > 0 {
+			topicRatio = float32(len(inputTopics)) / float32(max(len(inputTopics), candidateCount))
+		}
 
 		// Weighted combination of scores
-		finalScore := (textScore * 0.4) + // Direct text similarity
-			(topicScore * 0.3) + // Topic overlap
-			(lengthRatio * 0.15) + // Length similarity
-			(topicRatio * 0.15) // Topic count similarity
+		finalScore := (textScore * weights.text) + // Direct text similarity
+			(topicScore * weights.topic) + // Topic overlap
+			(lengthRatio * weights.length) + // Length similarity
+			(topicRatio * weights.topicCount) // Topic count similarity
+
+		// Reweight candidates from the requested categories
+		var categoryBoost float32
+		if options.scoped() {
+			categoryBoost = options.boostFor(match.storage.Categories(candidate))
+			finalScore += categoryBoost
+		}
 
 		scores = append(scores, TopicScore{
-			Question:   candidate,
-			TextScore:  textScore,
-			TopicScore: topicScore,
-			FinalScore: finalScore,
+			Question:      candidate,
+			TextScore:     textScore,
+			TopicScore:    topicScore,
+			LengthRatio:   lengthRatio,
+			TopicRatio:    topicRatio,
+			CategoryBoost: categoryBoost,
+			FinalScore:    finalScore,
 		})
 	}
 
@@ -130,53 +199,74 @@ func (match *TopicMatch) processTopicMatch(text string) []Answer {
 func (match *TopicMatch) extractTopics(text string) []string {
 	// Normalize text
 	text = strings.ToLower(text)
-	words := strings.Fields(text)
+	return match.filterTopics(strings.Fields(text))
+}
 
-	// Extract meaningful topics
-	topics := make([]string, 0)
-	for _, word := range words {
-		// Skip stop words and very short words
-		if !match.stopWords[word] && len(word) > 2 {
-			topics = append(topics, word)
+// candidateTokens returns the token set of a candidate kept by the storage,
+// or splits the candidate if the storage keeps none
+func (match *TopicMatch) candidateTokens(index storage.TokenSetIndex, candidate string) storage.TokenSet {
+	if index != nil {
+		if set, ok := index.TokenSet(candidate); ok {
+			return set
 		}
 	}
 
-	return topics
+	return storage.NewTokenSet(candidate)
 }
 
-// calculateTopicSimilarity calculates similarity between two sets of topics
-func (match *TopicMatch) calculateTopicSimilarity(topics1, topics2 []string) float32 {
-	if len(topics1) == 0 || len(topics2) == 0 {
-		return 0
+// countTopics counts the topics of a token set and the ones shared with the
+// input topics
+func (match *TopicMatch) countTopics(input map[string]bool, set storage.TokenSet) topicCounts {
+	var counts topicCounts
+	for i, token := range set.Tokens {
+		if !match.isTopic(token) {
+			continue
+		}
+
+		counts.distinct++
+		counts.total += set.Counts[i]
+		if input[token] {
+			counts.shared++
+		}
 	}
 
-	// Create sets for comparison
-	set1 := make(map[string]bool)
-	set2 := make(map[string]bool)
+	return counts
+}
 
-	for _, t := range topics1 {
-		set1[t] = true
-	}
-	for _, t := range topics2 {
-		set2[t] = true
+// filterTopics keeps the meaningful topics of lowercased words
+func (match *TopicMatch) filterTopics(words []string) []string {
+	// Extract meaningful topics
+	topics := make([]string, 0, len(words))
+	for _, word := range words {
+		if match.isTopic(word) {
+			topics = append(topics, word)
+		}
 	}
 
-	// Calculate intersection
-	intersection := 0
-	for topic := range set1 {
-		if set2[topic] {
-			intersection++
-		}
+	return topics
+}
+
+// isTopic tells whether a lowercased word is meaningful, neither a stop word
+// nor a very short word
+func (match *TopicMatch) isTopic(word string) bool {
+	return !match.stopWords[word] && len(word) >= match.minWordLength
+}
+
+// calculateTopicSimilarity calculates the Jaccard similarity between the set
+// of input topics and the topics of a candidate
+func (match *TopicMatch) calculateTopicSimilarity(set1 map[string]bool, set2 topicCounts) float32 {
+	if len(set1) == 0 || set2.distinct == 0 {
+		return 0
 	}
 
 	// Calculate union
-	union := len(set1) + len(set2) - intersection
+	union := len(set1) + set2.distinct - set2.shared
 
 	if union == 0 {
 		return 0
 	}
 
-	return float32(intersection) / float32(union)
+	return float32(set2.shared) / float32(union)
 }
 
 // convertToAnswers converts TopicScores to Answers
@@ -191,9 +281,6 @@ func (match *TopicMatch) convertToAnswers(scores []TopicScore) []Answer {
 		return answers
 	}
 
-	// Get highest score for normalization
-	maxScore := scores[0].FinalScore
-
 	for i := 0; i < tops; i++ {
 		if responses, ok := match.storage.Find(scores[i].Question); ok {
 			// Find best response by occurrence count
@@ -207,11 +294,26 @@ func (match *TopicMatch) convertToAnswers(scores []TopicScore) []Answer {
 			}
 
 			if bestResponse != "" {
-				// Normalize confidence score between 0 and 1
-				normalizedConfidence := scores[i].FinalScore / maxScore
+				// The weights add up to 1, only a category boost can push
+				// the score above it
+				confidence := scores[i].FinalScore
+				if confidence > 1 {
+					confidence = 1
+				}
 				answers = append(answers, Answer{
 					Content:    bestResponse,
-					Confidence: normalizedConfidence,
+					Confidence: confidence,
+					Question:   scores[i].Question,
+					Categories: match.storage.Categories(scores[i].Question),
+					Adapter:    topicMatchName,
+					Scores: &Scores{
+						Text:          scores[i].TextScore,
+						Topic:         scores[i].TopicScore,
+						LengthRatio:   scores[i].LengthRatio,
+						TopicRatio:    scores[i].TopicRatio,
+						CategoryBoost: scores[i].CategoryBoost,
+						Final:         scores[i].FinalScore,
+					},
 				})
 			}
 		}
@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golangChatBot/bot/adapters/storage"
+	"golangChatBot/bot/corpus"
+)
+
+// testQuestions are questions of tests/eval_set.yaml.
+var testQuestions = []string{
+	"what is the main purpose of periMICA",
+	"which software does periMICA use",
+	"how does periMICA work with sensors and actuators",
+	"which periMICA versions are available",
+	"what is periNODE 0-10V",
+	"how do I connect a 0-10V sensor to periNODE",
+	"can I use periNODE 0-10V with SCADA",
+}
+
+// loadTestStorage indexes the bundled corpora like the corpus trainer does.
+func loadTestStorage(tb testing.TB) storage.StorageAdapter {
+	tb.Helper()
+
+	files, err := filepath.Glob("../../../CorpusManager/CorpusTrainer/Corpus/en/*/*.yml")
+	if err != nil || len(files) == 0 {
+		tb.Fatalf("no corpus files: %v", err)
+	}
+	corpora, err := corpus.LoadCorpora(files)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	store := storage.NewMemoryStorage(storage.Config{})
+	for category, conversations := range corpora {
+		for _, conversation := range conversations {
+			for i := 1; i < len(conversation); i++ {
+				responses, ok := store.Find(conversation[i-1])
+				if !ok {
+					responses = make(map[string]int)
+				}
+				responses[conversation[i]]++
+				store.Update(conversation[i-1], responses)
+				store.AddCategory(conversation[i-1], category)
+			}
+		}
+	}
+	store.BuildIndex()
+
+	return store
+}
+
+// splittingStorage hides the token sets of a storage, so that TopicMatch
+// splits every candidate the way it did before they were kept.
+type splittingStorage struct {
+	storage.StorageAdapter
+}
+
+func TestTopicMatchTokenSets(t *testing.T) {
+	store := loadTestStorage(t)
+	match := NewTopicMatch(store, 5).(*TopicMatch)
+	splitting := NewTopicMatch(splittingStorage{store}, 5)
+
+	// the renamed question must be scored with its new topics
+	renamed := "how do I wire the actuators of a periNODE 0-10V"
+	if err := store.Rename("What types of software does the periMICA use?", renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, question := range append(testQuestions, renamed+" to SCADA") {
+		answers := match.Process(question)
+		if len(answers) == 0 {
+			t.Fatalf("no answers to %q", question)
+		}
+
+		// the search leaves out random keys of as many matches, so only
+		// the keys found by both are compared
+		split := scoresOf(splitting.Process(question))
+		for candidate, scores := range scoresOf(answers) {
+			if want, ok := split[candidate]; ok && scores != want {
+				t.Errorf("the token sets changed the scores of %q for %q: %v, want %v",
+					candidate, question, scores, want)
+			}
+		}
+
+		input := make(map[string]bool)
+		for _, topic := range match.extractTopics(question) {
+			input[topic] = true
+		}
+		for _, answer := range answers {
+			want := match.calculateTopicSimilarity(input, match.countTopics(input, storage.NewTokenSet(answer.Question)))
+			if answer.Scores.Topic != want {
+				t.Errorf("the topic score of %q for %q is %f, want %f", answer.Question, question, answer.Scores.Topic, want)
+			}
+		}
+	}
+}
+
+// scoresOf returns the scores of the matched questions.
+func scoresOf(answers []Answer) map[string]Scores {
+	scores := make(map[string]Scores, len(answers))
+	for _, answer := range answers {
+		scores[answer.Question] = *answer.Scores
+	}
+
+	return scores
+}
+
+func TestCalculateTopicSimilarity(t *testing.T) {
+	match := &TopicMatch{stopWords: initializeStopWords(), minWordLength: defaultMinWordLength}
+	input := map[string]bool{"perimica": true, "purpose": true}
+	candidate := match.countTopics(input, storage.NewTokenSet("the perimica primary purpose of perimica"))
+	if want := (topicCounts{shared: 2, distinct: 3, total: 4}); candidate != want {
+		t.Fatalf("got the topic counts %+v, want %+v", candidate, want)
+	}
+
+	// 2 shared topics out of 3 distinct ones
+	if got := match.calculateTopicSimilarity(input, candidate); got != float32(2)/3 {
+		t.Fatalf("got %f, want %f", got, float32(2)/3)
+	}
+	if got := match.calculateTopicSimilarity(input, topicCounts{}); got != 0 {
+		t.Fatalf("got %f for a candidate without topics, want 0", got)
+	}
+}
+
+func BenchmarkTopicMatch(b *testing.B) {
+	store := loadTestStorage(b)
+	configs := map[string]TopicMatchConfig{
+		"all features": DefaultTopicMatchConfig(),
+		"topic features": {
+			TopicWeight:      0.5,
+			TopicCountWeight: 0.5,
+			Features:         []string{FeatureTopic, FeatureTopicCount},
+		},
+	}
+
+	storages := map[string]storage.StorageAdapter{
+		"token sets": store,
+		// the baseline splits the candidates on every query
+		"uncached": splittingStorage{store},
+	}
+
+	for name, config := range configs {
+		b.Run(name, func(b *testing.B) {
+			for storageName, store := range storages {
+				b.Run(storageName, func(b *testing.B) {
+					match, err := NewTopicMatchWithConfig(store, 5, config)
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.ResetTimer()
+
+					for i := 0; i < b.N; i++ {
+						match.Process(testQuestions[i%len(testQuestions)])
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package logic
+
+import (
+	"fmt"
+	"strings"
+
+	"golangChatBot/bot/nlp"
+)
+
+// Scoring features of TopicMatch
+const (
+	FeatureText       = "text"
+	FeatureTopic      = "topic"
+	FeatureLength     = "length"
+	FeatureTopicCount = "topic_count"
+)
+
+const defaultMinWordLength = 3
+
+// TopicMatchConfig tunes the scoring of TopicMatch, it is read from the
+// topic_match section of the config file. The zero value gives the defaults.
+type TopicMatchConfig struct {
+	// Weights of the scoring features. If all of them are 0, the defaults
+	// 0.4, 0.3, 0.15 and 0.15 are used.
+	TextWeight       float32 `yaml:"text_weight"`
+	TopicWeight      float32 `yaml:"topic_weight"`
+	LengthWeight     float32 `yaml:"length_weight"`
+	TopicCountWeight float32 `yaml:"topic_count_weight"`
+	// MinWordLength is the length a word needs to count as a topic, 0 means 3.
+	MinWordLength int `yaml:"min_word_length"`
+	// StopWordsFiles replace the built-in English stop words. The files hold
+	// one word per line, the "count:" lines of generated files are skipped.
+	StopWordsFiles []string `yaml:"stop_words_files"`
+	// Features lists the scoring features to compute, out of text, topic,
+	// length and topic_count. Empty means all of them.
+	Features []string `yaml:"features"`
+}
+
+// topicWeights holds the weights of the enabled features, scaled to add up
+// to 1 so that the final score stays between 0 and 1.
+type topicWeights struct {
+	text       float32
+	topic      float32
+	length     float32
+	topicCount float32
+}
+
+// DefaultTopicMatchConfig returns the weights TopicMatch always used.
+func DefaultTopicMatchConfig() TopicMatchConfig {
+	return TopicMatchConfig{
+		TextWeight:       0.4,
+		TopicWeight:      0.3,
+		LengthWeight:     0.15,
+		TopicCountWeight: 0.15,
+		MinWordLength:    defaultMinWordLength,
+	}
+}
+
+// weights validates the config and returns the weights of the enabled features
+func (config TopicMatchConfig) weights() (topicWeights, error) {
+	if config.TextWeight == 0 && config.TopicWeight == 0 && config.LengthWeight == 0 && config.TopicCountWeight == 0 {
+		defaults := DefaultTopicMatchConfig()
+		config.TextWeight = defaults.TextWeight
+		config.TopicWeight = defaults.TopicWeight
+		config.LengthWeight = defaults.LengthWeight
+		config.TopicCountWeight = defaults.TopicCountWeight
+	}
+	if config.TextWeight < 0 || config.TopicWeight < 0 || config.LengthWeight < 0 || config.TopicCountWeight < 0 {
+		return topicWeights{}, fmt.Errorf("topic match weights must not be negative")
+	}
+
+	weights := topicWeights{
+		text:       config.TextWeight,
+		topic:      config.TopicWeight,
+		length:     config.LengthWeight,
+		topicCount: config.TopicCountWeight,
+	}
+
+	if len(config.Features) > 0 {
+		enabled := make(map[string]bool)
+		for _, feature := range config.Features {
+			switch feature = strings.ToLower(strings.TrimSpace(feature)); feature {
+			case FeatureText, FeatureTopic, FeatureLength, FeatureTopicCount:
+				enabled[feature] = true
+			default:
+				return topicWeights{}, fmt.Errorf("unknown topic match feature %q", feature)
+			}
+		}
+
+		if !enabled[FeatureText] {
+			weights.text = 0
+		}
+		if !enabled[FeatureTopic] {
+			weights.topic = 0
+		}
+		if !enabled[FeatureLength] {
+			weights.length = 0
+		}
+		if !enabled[FeatureTopicCount] {
+			weights.topicCount = 0
+		}
+	}
+
+	total := weights.text + weights.topic + weights.length + weights.topicCount
+	if total == 0 {
+		return topicWeights{}, fmt.Errorf("topic match needs at least one feature with a weight")
+	}
+
+	weights.text /= total
+	weights.topic /= total
+	weights.length /= total
+	weights.topicCount /= total
+
+	return weights, nil
+}
+
+// stopWords returns the stop words of the config files, or the built-in ones
+func (config TopicMatchConfig) stopWords() (map[string]bool, error) {
+	if len(config.StopWordsFiles) == 0 {
+		return initializeStopWords(), nil
+	}
+
+	stopWords := make(map[string]bool)
+	for _, file := range config.StopWordsFiles {
+		if err := loadStopWords(file, stopWords); err != nil {
+			return nil, fmt.Errorf("error loading stop words file %s: %v", file, err)
+		}
+	}
+
+	return stopWords, nil
+}
+
+// loadStopWords adds the lowercased words of file to stopWords
+func loadStopWords(file string, stopWords map[string]bool) error {
+	words, err := nlp.ReadWords(file)
+	if err != nil {
+		return err
+	}
+
+	for _, word := range words {
+		stopWords[strings.ToLower(word)] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadStopWords(t *testing.T) {
+	// the generated stop words files list the words by their counts
+	file := filepath.Join(t.TempDir(), "stopwords.txt")
+	content := "# stop words\n\nThe\n12:\n\tGateway \n3:\n\tof\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stopWords := map[string]bool{"a": true}
+	if err := loadStopWords(file, stopWords); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"a": true, "the": true, "gateway": true, "of": true}
+	if !reflect.DeepEqual(stopWords, want) {
+		t.Fatalf("got %v, want %v", stopWords, want)
+	}
+
+	if err := loadStopWords(filepath.Join(t.TempDir(), "missing.txt"), stopWords); err == nil {
+		t.Fatal("loading a missing file succeeded")
+	}
+}
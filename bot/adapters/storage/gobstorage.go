@@ -4,5 +4,11 @@ import "encoding/gob"
 
 type GobStorage interface {
 	StorageAdapter
+	TermIndex
+	TokenSetIndex
 	SetOutput(*gob.Encoder)
+	RestoreCategories(*gob.Decoder) error
+	RestoreStatistics(*gob.Decoder) error
+	// snapshot copies the storage for a model file.
+	snapshot() storageSnapshot
 }
@@ -2,20 +2,25 @@ package storage
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 
-	"github.com/wangbin/jiebago"
-	"github.com/wangbin/jiebago/analyse"
 	"github.com/zeromicro/go-zero/core/lang"
-	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/mr"
+
+	"golangChatBot/bot/nlp"
 )
 
 const (
 	chunkSize             = 10000
+	smallIndex            = 16
 	topKeywords           = 5
 	thresholdForKeywords  = 5
 	maxSearchResults      = 100
@@ -27,6 +32,13 @@ type Config struct {
 	IdfFile                string
 	StopWordsFile          string
 	GeneratedStopWordsFile string
+	// Language selects the question detector, empty means auto detection.
+	Language string
+	// Generations is the number of previous model files kept on Sync.
+	Generations int
+	// Tokenizer selects how texts are split into index terms, jieba or
+	// english, empty means jieba.
+	Tokenizer string
 }
 
 type (
@@ -35,24 +47,53 @@ type (
 		keys   []string
 	}
 
+	// indexStatistics are computed from the index by BuildIndex and UpdateIndex
+	// and saved with it, they are what ranking by BM25 needs. TokenSets holds
+	// the token set of each key, so that matching doesn't split the keys
+	// again on every query.
+	indexStatistics struct {
+		DocFreqs    map[string]int
+		DocLengths  []int
+		TotalLength int
+		TokenSets   []TokenSet
+	}
+
+	// storageSnapshot holds the sections of a memoryStorage written to a
+	// model file, copied under one lock.
+	storageSnapshot struct {
+		keys       []string
+		responses  map[string]map[string]int
+		indexes    map[string][]int
+		categories map[string][]string
+		statistics indexStatistics
+	}
+
+	// memoryStorage is safe for concurrent use. Readers share a read lock,
+	// BuildIndex builds the new index without holding the write lock and
+	// swaps it in when done.
+	//
+	// Removed and renamed keys leave tombstones in keys: their postings are
+	// gone, but their ids stay taken until Compact renumbers the index.
 	memoryStorage struct {
-		writer    *gob.Encoder
-		segmenter *jiebago.Segmenter
-		extracter *analyse.TagExtracter
-		keys      []string
-		responses map[string]map[string]int
-		indexes   map[string][]int
-		config    Config
+		lock       sync.RWMutex
+		writer     *gob.Encoder
+		tokenizer  nlp.Tokenizer
+		keys       []string
+		ids        map[string]int
+		tombstones map[int]lang.PlaceholderType
+		responses  map[string]map[string]int
+		indexes    map[string][]int
+		// terms holds the index terms of each key by id, so that a key is
+		// taken out of the postings it is in, whatever the tokenizer is now.
+		terms      [][]string
+		statistics indexStatistics
+		categories map[string][]string
+		config     Config
 	}
 )
 
 func RestoreMemoryStorage(decoder *gob.Decoder, config Config) (*memoryStorage, error) {
-	var segmenter jiebago.Segmenter
-	logx.Must(segmenter.LoadDictionary(config.DictFile))
-	var extracter analyse.TagExtracter
-	logx.Must(extracter.LoadDictionary(config.DictFile))
-	logx.Must(extracter.LoadIdf(config.IdfFile))
-	logx.Must(extracter.LoadStopWords(config.StopWordsFile))
+	tokenizer := newTokenizer(config)
 
 	var keys []string
 	responses := make(map[string]map[string]int)
@@ -70,54 +111,210 @@ func RestoreMemoryStorage(decoder *gob.Decoder, config Config) (*memoryStorage,
 		return nil, err
 	}
 
-	return &memoryStorage{
-		segmenter: &segmenter,
-		extracter: &extracter,
-		keys:      keys,
-		responses: responses,
-		indexes:   indexes,
-		config:    config,
-	}, nil
+	storage := &memoryStorage{
+		tokenizer:  tokenizer,
+		keys:       keys,
+		responses:  responses,
+		indexes:    indexes,
+		terms:      buildTerms(len(keys), indexes),
+		statistics: buildStatistics(keys, indexes),
+		categories: make(map[string][]string),
+		config:     config,
+	}
+	storage.resetIds()
+
+	return storage, nil
 }
 
 func NewMemoryStorage(config Config) *memoryStorage {
-	var segmenter jiebago.Segmenter
-	logx.Must(segmenter.LoadDictionary(config.DictFile))
-	var extracter analyse.TagExtracter
-	logx.Must(extracter.LoadDictionary(config.DictFile))
-	logx.Must(extracter.LoadIdf(config.IdfFile))
-	logx.Must(extracter.LoadStopWords(config.StopWordsFile))
-
 	return &memoryStorage{
-		segmenter: &segmenter,
-		extracter: &extracter,
-		responses: make(map[string]map[string]int),
-		indexes:   make(map[string][]int),
-		config:    config,
+		tokenizer:  newTokenizer(config),
+		ids:        make(map[string]int),
+		tombstones: make(map[int]lang.PlaceholderType),
+		responses:  make(map[string]map[string]int),
+		indexes:    make(map[string][]int),
+		statistics: buildStatistics(nil, nil),
+		categories: make(map[string][]string),
+		config:     config,
 	}
 }
 
+func (storage *memoryStorage) AddCategory(text, category string) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	for _, each := range storage.categories[text] {
+		if each == category {
+			return
+		}
+	}
+
+	storage.categories[text] = append(storage.categories[text], category)
+}
+
 func (storage *memoryStorage) BuildIndex() {
-	storage.keys = storage.buildKeys()
-	storage.indexes = storage.buildIndex(storage.keys)
+	storage.lock.RLock()
+	keys := storage.buildKeys()
+	storage.lock.RUnlock()
+
+	indexes := storage.buildIndex(keys, 0)
+	terms := buildTerms(len(keys), indexes)
+	statistics := buildStatistics(keys, indexes)
+
+	storage.lock.Lock()
+	storage.keys = keys
+	storage.indexes = indexes
+	storage.terms = terms
+	storage.statistics = statistics
+	storage.resetIds()
+	// the keys updated while the index was built
+	storage.index(storage.unindexed())
+	storage.lock.Unlock()
+
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
 	storage.saveStopWords()
 }
 
+func (storage *memoryStorage) Categories(text string) []string {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	categories := storage.categories[text]
+	if len(categories) == 0 {
+		return nil
+	}
+
+	result := make([]string, len(categories))
+	copy(result, categories)
+	return result
+}
+
+// AverageLength returns the average number of index terms of the keys.
+func (storage *memoryStorage) AverageLength() float64 {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	documents := storage.documents()
+	if documents == 0 {
+		return 0
+	}
+
+	return float64(storage.statistics.TotalLength) / float64(documents)
+}
+
+// Compact drops the tombstones of the removed and renamed keys from the keys
+// and renumbers the index, so that the model stops carrying them.
+func (storage *memoryStorage) Compact() {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if len(storage.tombstones) == 0 {
+		return
+	}
+
+	remap := make([]int, len(storage.keys))
+	keys := make([]string, 0, len(storage.keys)-len(storage.tombstones))
+	lengths := make([]int, 0, cap(keys))
+	tokenSets := make([]TokenSet, 0, cap(keys))
+	terms := make([][]string, 0, cap(keys))
+	for id, key := range storage.keys {
+		if _, ok := storage.tombstones[id]; ok {
+			remap[id] = -1
+			continue
+		}
+
+		remap[id] = len(keys)
+		keys = append(keys, key)
+		if id < len(storage.statistics.DocLengths) {
+			lengths = append(lengths, storage.statistics.DocLengths[id])
+		} else {
+			lengths = append(lengths, 0)
+		}
+		if id < len(storage.statistics.TokenSets) {
+			tokenSets = append(tokenSets, storage.statistics.TokenSets[id])
+		} else {
+			tokenSets = append(tokenSets, NewTokenSet(key))
+		}
+		if id < len(storage.terms) {
+			terms = append(terms, storage.terms[id])
+		} else {
+			terms = append(terms, nil)
+		}
+	}
+
+	for term, ids := range storage.indexes {
+		compacted := ids[:0]
+		for _, id := range ids {
+			if id < len(remap) && remap[id] >= 0 {
+				compacted = append(compacted, remap[id])
+			}
+		}
+		if len(compacted) == 0 {
+			delete(storage.indexes, term)
+			delete(storage.statistics.DocFreqs, term)
+		} else {
+			storage.indexes[term] = compacted
+		}
+	}
+
+	storage.keys = keys
+	storage.terms = terms
+	storage.statistics.DocLengths = lengths
+	storage.statistics.TokenSets = tokenSets
+	storage.resetIds()
+}
+
 func (storage *memoryStorage) Count() int {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
 	return len(storage.responses)
 }
 
+// DocumentFrequency returns the number of keys indexed under term.
+func (storage *memoryStorage) DocumentFrequency(term string) int {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	return storage.statistics.DocFreqs[term]
+}
+
+// Documents returns the number of indexed keys.
+func (storage *memoryStorage) Documents() int {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	return storage.documents()
+}
+
+// Find returns a copy of the responses to text, so callers may modify it and
+// hand it back to Update.
 func (storage *memoryStorage) Find(text string) (map[string]int, bool) {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
 	value, ok := storage.responses[text]
-	return value, ok
+	if !ok {
+		return nil, false
+	}
+
+	return copyResponses(value), true
 }
 
 func (storage *memoryStorage) Search(key string) []string {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
 	ids := make(map[int]int8)
 	var maxMatches int8
 	collector := func(word string) {
 		if wordIds, ok := storage.indexes[word]; ok {
 			for _, id := range wordIds {
+				// models restored with removed keys still index them
+				if _, ok := storage.tombstones[id]; ok {
+					continue
+				}
 				current := ids[id]
 				ids[id] = current + 1
 				if current+1 > maxMatches {
@@ -127,15 +324,12 @@ func (storage *memoryStorage) Search(key string) []string {
 		}
 	}
 
-	if len([]rune(key)) > thresholdForKeywords {
-		tags := storage.extracter.ExtractTags(key, topKeywords)
-		for i := range tags {
-			collector(tags[i].Text())
-		}
+	for _, term := range storage.tokenizer.Tokenize(key) {
+		collector(term)
 	}
 
-	if len(ids) == 0 {
-		for word := range storage.segmenter.Cut(key, true) {
+	if tokenizer, ok := storage.tokenizer.(fallbackTokenizer); ok && len(ids) == 0 {
+		for _, word := range tokenizer.Words(key) {
 			collector(word)
 		}
 	}
@@ -147,15 +341,156 @@ func (storage *memoryStorage) Search(key string) []string {
 	}
 }
 
+// Postings returns the keys indexed under term with their numbers of terms.
+func (storage *memoryStorage) Postings(term string) []Posting {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	ids := storage.indexes[term]
+	postings := make([]Posting, 0, len(ids))
+	for _, id := range ids {
+		if id >= len(storage.keys) || id >= len(storage.statistics.DocLengths) {
+			continue
+		}
+		if _, ok := storage.tombstones[id]; ok {
+			continue
+		}
+
+		postings = append(postings, Posting{
+			Key:    storage.keys[id],
+			Length: storage.statistics.DocLengths[id],
+		})
+	}
+
+	return postings
+}
+
+// Remove deletes text with its responses and categories, and takes it out
+// of the index.
 func (storage *memoryStorage) Remove(text string) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
 	delete(storage.responses, text)
+	delete(storage.categories, text)
+	if id, ok := storage.ids[text]; ok {
+		storage.unindex(id)
+	}
+}
+
+// Rename moves the responses and categories of text to newText and indexes
+// newText in place of text, if text was indexed.
+func (storage *memoryStorage) Rename(text, newText string) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	responses, ok := storage.responses[text]
+	if !ok {
+		return fmt.Errorf("%q is not stored", text)
+	}
+	if text == newText {
+		return nil
+	}
+	if _, ok := storage.responses[newText]; ok {
+		return fmt.Errorf("%q is already stored", newText)
+	}
+
+	delete(storage.responses, text)
+	storage.responses[newText] = responses
+	if categories, ok := storage.categories[text]; ok {
+		delete(storage.categories, text)
+		storage.categories[newText] = categories
+	}
+
+	if id, ok := storage.ids[text]; ok {
+		storage.unindex(id)
+		storage.index([]string{newText})
+	}
+
+	return nil
+}
+
+// ReplaceAnswer replaces the response answer to text with newAnswer, which
+// takes over its count. The index is left as it is.
+func (storage *memoryStorage) ReplaceAnswer(text, answer, newAnswer string) error {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	responses, ok := storage.responses[text]
+	if !ok {
+		return fmt.Errorf("%q is not stored", text)
+	}
+	count, ok := responses[answer]
+	if !ok {
+		return fmt.Errorf("%q is not a response to %q", answer, text)
+	}
+
+	delete(responses, answer)
+	responses[newAnswer] += count
+
+	return nil
+}
+
+// RestoreCategories reads the categories written by SyncCategories. Models
+// written before categories were kept end without them, which is not an error.
+func (storage *memoryStorage) RestoreCategories(decoder *gob.Decoder) error {
+	categories := make(map[string][]string)
+	if err := decoder.Decode(&categories); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	storage.lock.Lock()
+	storage.categories = categories
+	storage.lock.Unlock()
+
+	return nil
+}
+
+// RestoreStatistics reads the index statistics written by SyncStatistics.
+// Models written before they were kept end without them, the statistics
+// computed from the restored index are kept then.
+func (storage *memoryStorage) RestoreStatistics(decoder *gob.Decoder) error {
+	var statistics indexStatistics
+	if err := decoder.Decode(&statistics); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	if len(statistics.DocLengths) != len(storage.keys) {
+		return fmt.Errorf("index statistics cover %d keys, the index has %d",
+			len(statistics.DocLengths), len(storage.keys))
+	}
+	if statistics.DocFreqs == nil {
+		statistics.DocFreqs = make(map[string]int)
+	}
+	// models written before the token sets were kept have none
+	if len(statistics.TokenSets) != len(storage.keys) {
+		statistics.TokenSets = storage.statistics.TokenSets
+	}
+	storage.statistics = statistics
+
+	return nil
 }
 
 func (storage *memoryStorage) SetOutput(output *gob.Encoder) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
 	storage.writer = output
 }
 
 func (storage *memoryStorage) Sync() error {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
 	if err := storage.writer.Encode(storage.keys); err != nil {
 		return err
 	}
@@ -167,8 +502,109 @@ func (storage *memoryStorage) Sync() error {
 	return storage.writer.Encode(storage.indexes)
 }
 
+// snapshot copies the sections saved in a model file under one read lock,
+// so that they agree with each other even if the storage is updated while
+// they are encoded.
+func (storage *memoryStorage) snapshot() storageSnapshot {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	responses := make(map[string]map[string]int, len(storage.responses))
+	for key, value := range storage.responses {
+		responses[key] = copyResponses(value)
+	}
+
+	indexes := make(map[string][]int, len(storage.indexes))
+	for term, ids := range storage.indexes {
+		indexes[term] = append([]int(nil), ids...)
+	}
+
+	categories := make(map[string][]string, len(storage.categories))
+	for key, value := range storage.categories {
+		categories[key] = append([]string(nil), value...)
+	}
+
+	docFreqs := make(map[string]int, len(storage.statistics.DocFreqs))
+	for term, count := range storage.statistics.DocFreqs {
+		docFreqs[term] = count
+	}
+
+	return storageSnapshot{
+		keys:       append([]string(nil), storage.keys...),
+		responses:  responses,
+		indexes:    indexes,
+		categories: categories,
+		statistics: indexStatistics{
+			DocFreqs:    docFreqs,
+			DocLengths:  append([]int(nil), storage.statistics.DocLengths...),
+			TotalLength: storage.statistics.TotalLength,
+			// the token set of a key is never modified, only replaced.
+			TokenSets: append([]TokenSet(nil), storage.statistics.TokenSets...),
+		},
+	}
+}
+
+// Terms splits text into index terms the way Search does, falling back to
+// the words of the text if none of its terms is indexed.
+func (storage *memoryStorage) Terms(text string) []string {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	terms := uniqueTerms(storage.tokenizer.Tokenize(text))
+	for _, term := range terms {
+		if _, ok := storage.indexes[term]; ok {
+			return terms
+		}
+	}
+
+	if tokenizer, ok := storage.tokenizer.(fallbackTokenizer); ok {
+		return uniqueTerms(tokenizer.Words(text))
+	}
+
+	return terms
+}
+
+// Update sets the responses to text and indexes text if it is new, so that
+// it is found right away.
 func (storage *memoryStorage) Update(text string, responses map[string]int) {
+	responses = copyResponses(responses)
+
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
 	storage.responses[text] = responses
+	if _, ok := storage.ids[text]; !ok {
+		storage.index([]string{text})
+	}
+}
+
+// UpdateIndex indexes the keys added since the last BuildIndex or UpdateIndex
+// and appends them to the existing index, leaving the known keys untouched.
+func (storage *memoryStorage) UpdateIndex() {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	added := storage.unindexed()
+	if len(added) == 0 {
+		return
+	}
+
+	storage.index(added)
+	storage.saveStopWords()
+}
+
+// TokenSet returns the token set of key, false if key is not indexed. The
+// token set is shared and must not be modified.
+func (storage *memoryStorage) TokenSet(key string) (TokenSet, bool) {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	id, ok := storage.ids[key]
+	if !ok || id >= len(storage.statistics.TokenSets) {
+		return TokenSet{}, false
+	}
+
+	return storage.statistics.TokenSets[id], true
 }
 
 func (storage *memoryStorage) buildKeys() []string {
@@ -183,10 +619,125 @@ func (storage *memoryStorage) buildKeys() []string {
 	return keys
 }
 
-func (storage *memoryStorage) buildIndex(keys []string) map[string][]int {
+// unindexed returns the stored keys that are not indexed. The lock must be held.
+func (storage *memoryStorage) unindexed() []string {
+	var keys []string
+	for key := range storage.responses {
+		if _, ok := storage.ids[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// documents returns the number of keys that are not tombstones.
+func (storage *memoryStorage) documents() int {
+	return len(storage.statistics.DocLengths) - len(storage.tombstones)
+}
+
+// index appends keys to the keys and adds their postings to the index and
+// its statistics. The write lock must be held.
+func (storage *memoryStorage) index(keys []string) {
+	if storage.indexes == nil {
+		storage.indexes = make(map[string][]int)
+	}
+
+	offset := len(storage.keys)
+	storage.keys = append(storage.keys, keys...)
+	for i, key := range keys {
+		storage.ids[key] = offset + i
+	}
+
+	storage.statistics.DocLengths = append(storage.statistics.DocLengths, make([]int, len(keys))...)
+	lengths := storage.statistics.DocLengths
+	for _, key := range keys {
+		storage.statistics.TokenSets = append(storage.statistics.TokenSets, NewTokenSet(key))
+	}
+	storage.terms = append(storage.terms, make([][]string, len(storage.keys)-len(storage.terms))...)
+	for word, ids := range storage.buildIndex(keys, offset) {
+		storage.indexes[word] = append(storage.indexes[word], ids...)
+		storage.statistics.DocFreqs[word] += len(ids)
+		for _, id := range ids {
+			lengths[id]++
+			storage.statistics.TotalLength++
+			storage.terms[id] = append(storage.terms[id], word)
+		}
+	}
+}
+
+// unindex takes the key with id out of the postings it was indexed under and
+// the statistics and leaves a tombstone in its place. The write lock must be
+// held.
+func (storage *memoryStorage) unindex(id int) {
+	key := storage.keys[id]
+	var terms []string
+	if id < len(storage.terms) {
+		terms = storage.terms[id]
+		storage.terms[id] = nil
+	}
+	for _, term := range terms {
+		ids := storage.indexes[term]
+		for i := range ids {
+			if ids[i] == id {
+				ids = append(ids[:i], ids[i+1:]...)
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(storage.indexes, term)
+			delete(storage.statistics.DocFreqs, term)
+		} else {
+			storage.indexes[term] = ids
+			storage.statistics.DocFreqs[term] = len(ids)
+		}
+	}
+
+	if id < len(storage.statistics.DocLengths) {
+		storage.statistics.TotalLength -= storage.statistics.DocLengths[id]
+		storage.statistics.DocLengths[id] = 0
+	}
+	if id < len(storage.statistics.TokenSets) {
+		storage.statistics.TokenSets[id] = TokenSet{}
+	}
+	if storage.ids[key] == id {
+		delete(storage.ids, key)
+	}
+	storage.tombstones[id] = lang.Placeholder
+}
+
+// resetIds maps the keys to their ids. Keys that were removed, or indexed
+// again later under a new id, are tombstones. The write lock must be held.
+func (storage *memoryStorage) resetIds() {
+	storage.ids = make(map[string]int, len(storage.keys))
+	for id, key := range storage.keys {
+		storage.ids[key] = id
+	}
+
+	storage.tombstones = make(map[int]lang.PlaceholderType)
+	for id, key := range storage.keys {
+		if _, ok := storage.responses[key]; !ok || storage.ids[key] != id {
+			storage.tombstones[id] = lang.Placeholder
+		}
+	}
+	for key, id := range storage.ids {
+		if _, ok := storage.tombstones[id]; ok {
+			delete(storage.ids, key)
+		}
+	}
+}
+
+// buildIndex indexes keys, which are stored in storage.keys from offset on.
+func (storage *memoryStorage) buildIndex(keys []string, offset int) map[string][]int {
+	// a few keys, like the ones of live edits, are indexed in place
+	if len(keys) <= smallIndex {
+		return storage.indexChunk(&keyChunk{offset: offset, keys: keys})
+	}
+
 	result, err := mr.MapReduce(func(source chan<- *keyChunk) {
 		chunks := splitStrings(keys, chunkSize)
 		for i := range chunks {
+			chunks[i].offset += offset
 			source <- chunks[i]
 		}
 		// Removed the explicit close(source) call
@@ -300,6 +851,11 @@ func (storage *memoryStorage) generateSearchResults(ids []int) []string {
 }
 
 func (storage *memoryStorage) mapper(chunk *keyChunk, writer mr.Writer[map[string][]int], cancel func(error)) {
+	writer.Write(storage.indexChunk(chunk))
+}
+
+// indexChunk returns the postings of the keys of chunk.
+func (storage *memoryStorage) indexChunk(chunk *keyChunk) map[string][]int {
 	indexes := make(map[string][]int)
 
 	for i := range chunk.keys {
@@ -313,20 +869,12 @@ func (storage *memoryStorage) mapper(chunk *keyChunk, writer mr.Writer[map[strin
 			}
 		}
 
-		key := chunk.keys[i]
-		if len([]rune(key)) > thresholdForKeywords {
-			tags := storage.extracter.ExtractTags(key, topKeywords)
-			for i := range tags {
-				collector(tags[i].Text())
-			}
-		} else {
-			for word := range storage.segmenter.Cut(key, true) {
-				collector(word)
-			}
+		for _, term := range storage.tokenizer.Tokenize(chunk.keys[i]) {
+			collector(term)
 		}
 	}
 
-	writer.Write(indexes)
+	return indexes
 }
 
 func (storage *memoryStorage) reducer(input <-chan map[string][]int, writer mr.Writer[map[string][]int], cancel func(error)) {
@@ -359,6 +907,86 @@ func (storage *memoryStorage) reducer(input <-chan map[string][]int, writer mr.W
 	writer.Write(result)
 }
 
+// buildStatistics counts the keys of each term and the terms of each key,
+// and builds the token sets of the keys.
+func buildStatistics(keys []string, indexes map[string][]int) indexStatistics {
+	statistics := indexStatistics{
+		DocFreqs:   make(map[string]int, len(indexes)),
+		DocLengths: make([]int, len(keys)),
+		TokenSets:  make([]TokenSet, len(keys)),
+	}
+
+	for id, key := range keys {
+		statistics.TokenSets[id] = NewTokenSet(key)
+	}
+	for term, ids := range indexes {
+		statistics.DocFreqs[term] = len(ids)
+		for _, id := range ids {
+			if id < len(keys) {
+				statistics.DocLengths[id]++
+				statistics.TotalLength++
+			}
+		}
+	}
+
+	return statistics
+}
+
+// buildTerms lists the terms each of the size keys is indexed under.
+func buildTerms(size int, indexes map[string][]int) [][]string {
+	terms := make([][]string, size)
+	for term, ids := range indexes {
+		for _, id := range ids {
+			if id < size {
+				terms[id] = append(terms[id], term)
+			}
+		}
+	}
+
+	return terms
+}
+
+// NewTokenSet splits text into its lowercased words, the way TopicMatch
+// splits the texts it is asked.
+func NewTokenSet(text string) TokenSet {
+	var set TokenSet
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		// texts have few words, a linear scan beats building a map
+		if i := slices.Index(set.Tokens, word); i >= 0 {
+			set.Counts[i]++
+		} else {
+			set.Tokens = append(set.Tokens, word)
+			set.Counts = append(set.Counts, 1)
+		}
+	}
+
+	return set
+}
+
+// uniqueTerms drops the repeated and the blank terms.
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]lang.PlaceholderType, len(terms))
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if _, ok := seen[term]; ok || len(strings.TrimSpace(term)) == 0 {
+			continue
+		}
+		seen[term] = lang.Placeholder
+		result = append(result, term)
+	}
+
+	return result
+}
+
+func copyResponses(responses map[string]int) map[string]int {
+	result := make(map[string]int, len(responses))
+	for key, value := range responses {
+		result[key] = value
+	}
+
+	return result
+}
+
 func splitStrings(slice []string, size int) []*keyChunk {
 	var result []*keyChunk
 	count := len(slice)
@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"golangChatBot/bot/nlp"
+)
+
+func newTestStorage(t *testing.T, keys int) *memoryStorage {
+	t.Helper()
+
+	storage := NewMemoryStorage(Config{})
+	for i := 0; i < keys; i++ {
+		storage.Update(fmt.Sprintf("what does gateway %d do", i), map[string]int{"it forwards data": 1})
+	}
+	storage.BuildIndex()
+
+	return storage
+}
+
+func TestSearchWhileUpdating(t *testing.T) {
+	storage := newTestStorage(t, 100)
+
+	var readers, writers sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, key := range storage.Search("what does gateway 7 do") {
+					storage.Find(key)
+					storage.Categories(key)
+					storage.TokenSet(key)
+				}
+				storage.Postings("gateway")
+				storage.Terms("gateway sensor")
+				storage.AverageLength()
+			}
+		}()
+	}
+
+	writers.Add(1)
+	go func() {
+		defer writers.Done()
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("how is sensor %d wired", i)
+			storage.Update(key, map[string]int{"with two wires": 1})
+			storage.AddCategory(key, "sensors")
+			storage.UpdateIndex()
+			switch i % 4 {
+			case 0:
+				storage.Remove(key)
+			case 1:
+				storage.Rename(key, key+" again")
+			case 2:
+				storage.ReplaceAnswer(key, "with two wires", "with four wires")
+			case 3:
+				storage.Compact()
+			}
+			if i%50 == 0 {
+				storage.BuildIndex()
+			}
+		}
+	}()
+
+	writers.Wait()
+	close(done)
+	readers.Wait()
+
+	if _, ok := storage.Find("what does gateway 7 do"); !ok {
+		t.Fatal("a key was lost while updating")
+	}
+	if result := storage.Search("how is sensor 5 wired again"); len(result) == 0 {
+		t.Fatal("a renamed key is not searchable")
+	}
+}
+
+// checkUnindexed fails if key is still found or in any postings.
+func checkUnindexed(t *testing.T, storage *memoryStorage, key string) {
+	t.Helper()
+
+	if slices.Contains(storage.Search(key), key) {
+		t.Errorf("Search(%q) still finds it", key)
+	}
+	for term, ids := range storage.indexes {
+		for _, id := range ids {
+			if storage.keys[id] == key {
+				t.Errorf("%q is still indexed under %q", key, term)
+			}
+		}
+		if storage.statistics.DocFreqs[term] != len(ids) {
+			t.Errorf("the document frequency of %q is %d, it has %d postings",
+				term, storage.statistics.DocFreqs[term], len(ids))
+		}
+	}
+}
+
+func TestRemoveUnindexes(t *testing.T) {
+	storage := newTestStorage(t, 10)
+	key := "what does gateway 3 do"
+
+	storage.Remove(key)
+	checkUnindexed(t, storage, key)
+	if result := storage.Search("what does gateway 4 do"); !slices.Contains(result, "what does gateway 4 do") {
+		t.Fatalf("the other keys were unindexed too: %q", result)
+	}
+
+	storage.Rename("what does gateway 5 do", "what does the sensor do")
+	checkUnindexed(t, storage, "what does gateway 5 do")
+	if result := storage.Search("sensor"); !slices.Contains(result, "what does the sensor do") {
+		t.Fatalf("the renamed key is not found: %q", result)
+	}
+}
+
+func TestRemoveAfterTokenizerChange(t *testing.T) {
+	storage := newTestStorage(t, 10)
+	key := "what does gateway 3 do"
+
+	// a tokenizer leaving out terms the key was indexed under
+	storage.tokenizer = nlp.NewEnglishTokenizer("gateway")
+	storage.Remove(key)
+	checkUnindexed(t, storage, key)
+	for _, posting := range storage.Postings("gateway") {
+		if posting.Key == key {
+			t.Fatalf("%q is still posted under %q", key, "gateway")
+		}
+	}
+}
+
+func TestSearchSkipsRestoredTombstones(t *testing.T) {
+	storage := newTestStorage(t, 10)
+	removed := "what does gateway 3 do"
+
+	// a model whose index still holds a key without responses
+	snapshot := storage.snapshot()
+	delete(snapshot.responses, removed)
+	var buffer bytes.Buffer
+	encoder := gob.NewEncoder(&buffer)
+	for _, section := range []any{snapshot.keys, snapshot.responses, snapshot.indexes} {
+		if err := encoder.Encode(section); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored, err := RestoreMemoryStorage(gob.NewDecoder(&buffer), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := restored.Search(removed); slices.Contains(result, removed) {
+		t.Fatalf("Search(%q) finds the key without responses: %q", removed, result)
+	}
+	for _, posting := range restored.Postings("gateway") {
+		if posting.Key == removed {
+			t.Fatalf("%q is posted under %q", removed, "gateway")
+		}
+	}
+	if result := restored.Search("what does gateway 4 do"); !slices.Contains(result, "what does gateway 4 do") {
+		t.Fatalf("the other keys are not found: %q", result)
+	}
+}
+
+func TestUpdateIndexesNewKeys(t *testing.T) {
+	storage := newTestStorage(t, 10)
+	key := "how is the sensor wired"
+
+	storage.Update(key, map[string]int{"with two wires": 1})
+	if result := storage.Search("sensor wired"); !slices.Contains(result, key) {
+		t.Fatalf("Search(%q) = %q, the updated key is not found", "sensor wired", result)
+	}
+	if _, ok := storage.TokenSet(key); !ok {
+		t.Fatalf("TokenSet(%q) is not known", key)
+	}
+
+	// updating a known key keeps its id
+	id := storage.ids[key]
+	storage.Update(key, map[string]int{"with four wires": 1})
+	if storage.ids[key] != id || len(storage.keys) != 11 {
+		t.Fatalf("updating %q indexed it again", key)
+	}
+}
+
+// postingKeys returns the sorted keys posted under each term of storage.
+func postingKeys(storage *memoryStorage) map[string][]string {
+	result := make(map[string][]string)
+	for term := range storage.indexes {
+		var keys []string
+		for _, posting := range storage.Postings(term) {
+			keys = append(keys, posting.Key)
+		}
+		if len(keys) > 0 {
+			slices.Sort(keys)
+			result[term] = keys
+		}
+	}
+
+	return result
+}
+
+func TestCompact(t *testing.T) {
+	storage := newTestStorage(t, 10)
+	storage.Remove("what does gateway 3 do")
+	storage.Rename("what does gateway 5 do", "what does the sensor do")
+	storage.Update("how is the sensor wired", map[string]int{"with two wires": 1})
+	storage.Compact()
+
+	// a storage built from scratch with the keys left
+	built := NewMemoryStorage(Config{})
+	for key, responses := range storage.responses {
+		built.Update(key, responses)
+	}
+	built.BuildIndex()
+
+	if len(storage.tombstones) > 0 || len(storage.keys) != storage.Count() {
+		t.Fatalf("%d keys and %d tombstones are left for %d texts",
+			len(storage.keys), len(storage.tombstones), storage.Count())
+	}
+	if got, want := postingKeys(storage), postingKeys(built); !reflect.DeepEqual(got, want) {
+		t.Errorf("postings = %q, want %q", got, want)
+	}
+	for term, want := range built.statistics.DocFreqs {
+		if got := storage.DocumentFrequency(term); got != want {
+			t.Errorf("DocumentFrequency(%q) = %d, want %d", term, got, want)
+		}
+	}
+	for term, got := range storage.statistics.DocFreqs {
+		if got > 0 && built.statistics.DocFreqs[term] == 0 {
+			t.Errorf("DocumentFrequency(%q) = %d, want 0", term, got)
+		}
+	}
+	for key := range storage.responses {
+		got, _ := storage.TokenSet(key)
+		want, _ := built.TokenSet(key)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TokenSet(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if _, ok := storage.TokenSet("what does gateway 5 do"); ok {
+		t.Error("the renamed key still has a token set")
+	}
+	if got, want := storage.AverageLength(), built.AverageLength(); got != want {
+		t.Errorf("AverageLength() = %v, want %v", got, want)
+	}
+}
+
+func TestNewTokenSet(t *testing.T) {
+	tests := []struct {
+		text string
+		want TokenSet
+	}{
+		{"", TokenSet{}},
+		{"What is the periMICA?", TokenSet{Tokens: []string{"what", "is", "the", "perimica?"}, Counts: []int{1, 1, 1, 1}}},
+		{"the gateway  and THE Gateway", TokenSet{Tokens: []string{"the", "gateway", "and"}, Counts: []int{2, 2, 1}}},
+	}
+	for _, test := range tests {
+		if got := NewTokenSet(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("NewTokenSet(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// ModelFormatVersion is the version of the model files written by Sync.
+	ModelFormatVersion = 2
+	// legacyModelFormatVersion marks the headerless files written before
+	// model files were versioned.
+	legacyModelFormatVersion = 1
+
+	modelMagic    = "PCHMODEL"
+	maxHeaderSize = 64 << 20
+)
+
+var (
+	ErrModelTruncated = errors.New("model file is truncated")
+	ErrModelChecksum  = errors.New("model file checksum mismatch, the file is corrupted")
+)
+
+type (
+	// ModelHeader describes a model file, it precedes the gob encoded storages.
+	ModelHeader struct {
+		Version   int
+		CreatedAt time.Time
+		// Corpora maps the content hashes of the trained corpus files to their names.
+		Corpora   map[string]string
+		Tokenizer TokenizerConfig
+		// Keys is the number of stored questions and sentences.
+		Keys        int
+		PayloadSize int64
+		// Checksum is the hex encoded SHA-256 of the payload.
+		Checksum string
+	}
+
+	// TokenizerConfig records the configuration the model was indexed with.
+	TokenizerConfig struct {
+		// Name is the tokenizer, empty for models indexed before it could be chosen.
+		Name          string
+		DictFile      string
+		IdfFile       string
+		StopWordsFile string
+		Language      string
+	}
+)
+
+// GenerationPath returns the path of the generation-th previous model file
+// kept for path, 1 being the most recent.
+func GenerationPath(path string, generation int) string {
+	return path + "." + strconv.Itoa(generation)
+}
+
+// RollbackModel replaces the model file at path with its generation-th
+// previous version, after making sure that version loads. The replaced model
+// becomes generation 1 and the ones in between move up by one, so no
+// generation is lost and rolling back to 1 undoes the rollback.
+func RollbackModel(path string, generation int) error {
+	previous := GenerationPath(path, generation)
+	header, payload, err := readModel(previous)
+	if err == nil {
+		err = verifyPayload(header, payload)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", previous, err)
+	}
+
+	// set the generation aside, so that shifting the newer ones doesn't
+	// overwrite it.
+	rollback := path + ".rollback"
+	if err := os.Rename(previous, rollback); err != nil {
+		return err
+	}
+	if err := keepGenerations(path, generation); err != nil {
+		os.Rename(rollback, previous)
+		return err
+	}
+	if err := os.Rename(rollback, path); err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// ReadModelHeader reads and validates the header of the model file at path.
+// Headerless files written before versioning return a header with Version 1.
+func ReadModelHeader(path string) (*ModelHeader, error) {
+	header, _, err := readModel(path)
+	return header, err
+}
+
+func newTokenizerConfig(config Config) TokenizerConfig {
+	return TokenizerConfig{
+		Name:          tokenizerName(config),
+		DictFile:      config.DictFile,
+		IdfFile:       config.IdfFile,
+		StopWordsFile: config.StopWordsFile,
+		Language:      config.Language,
+	}
+}
+
+// checkIndexedWith returns an error if the model indexed with indexed can't
+// be searched with the tokenizer of current, and warnings about the settings
+// that may change a few of its terms.
+func checkIndexedWith(indexed, current TokenizerConfig) ([]string, error) {
+	// models indexed before the tokenizer could be chosen used jieba with
+	// dictionaries that were not recorded, the words of short questions are
+	// the same without them.
+	if len(indexed.Name) == 0 {
+		if current.Name != TokenizerJieba {
+			return nil, fmt.Errorf("the model was indexed with the jieba tokenizer, retrain it to use %s",
+				current.describe())
+		}
+		if !current.usesDictionaries() {
+			return []string{"the model was indexed with the jieba dictionaries, retrain it to index the keywords of long questions without them"}, nil
+		}
+		return nil, nil
+	}
+
+	if indexed.Name != current.Name || indexed.usesDictionaries() != current.usesDictionaries() {
+		return nil, fmt.Errorf("the model was indexed with %s, retrain it to use %s",
+			indexed.describe(), current.describe())
+	}
+
+	var warnings []string
+	for _, setting := range []struct {
+		name             string
+		indexed, current string
+	}{
+		{"dict_file", indexed.DictFile, current.DictFile},
+		{"idf_file", indexed.IdfFile, current.IdfFile},
+		{"stop_words_file", indexed.StopWordsFile, current.StopWordsFile},
+		{"language", indexed.Language, current.Language},
+	} {
+		if setting.indexed != setting.current {
+			warnings = append(warnings, fmt.Sprintf("the model was indexed with %s %q, it is %q now, retrain it if the terms differ",
+				setting.name, setting.indexed, setting.current))
+		}
+	}
+
+	return warnings, nil
+}
+
+// usesDictionaries tells whether jieba splits the texts with its dictionaries
+// rather than the built-in word splitting.
+func (config TokenizerConfig) usesDictionaries() bool {
+	return config.Name == TokenizerJieba && len(config.DictFile) > 0
+}
+
+func (config TokenizerConfig) describe() string {
+	switch {
+	case config.usesDictionaries():
+		return "the jieba tokenizer and its dictionaries"
+	case config.Name == TokenizerJieba:
+		return "the jieba tokenizer without dictionaries"
+	default:
+		return "the " + config.Name + " tokenizer"
+	}
+}
+
+// readModel returns the header and the payload of the model file at path,
+// after checking the format version, the payload size and the checksum.
+func readModel(path string) (*ModelHeader, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.HasPrefix(content, []byte(modelMagic)) {
+		return &ModelHeader{
+			Version:     legacyModelFormatVersion,
+			PayloadSize: int64(len(content)),
+		}, content, nil
+	}
+
+	reader := bytes.NewReader(content[len(modelMagic):])
+	var size uint32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return nil, nil, ErrModelTruncated
+	}
+	if size > maxHeaderSize || int64(size) > int64(reader.Len()) {
+		return nil, nil, ErrModelTruncated
+	}
+
+	headerBytes := make([]byte, size)
+	if _, err := io.ReadFull(reader, headerBytes); err != nil {
+		return nil, nil, ErrModelTruncated
+	}
+
+	var header ModelHeader
+	if err := gob.NewDecoder(bytes.NewReader(headerBytes)).Decode(&header); err != nil {
+		return nil, nil, fmt.Errorf("invalid model header: %w", err)
+	}
+
+	if header.Version > ModelFormatVersion {
+		return nil, nil, fmt.Errorf("model format version %d is newer than the supported version %d",
+			header.Version, ModelFormatVersion)
+	}
+	if header.Version < ModelFormatVersion {
+		return nil, nil, fmt.Errorf("model format version %d is not supported", header.Version)
+	}
+
+	payload := content[len(content)-reader.Len():]
+	if int64(len(payload)) < header.PayloadSize {
+		return nil, nil, ErrModelTruncated
+	}
+	if checksum(payload) != header.Checksum {
+		return nil, nil, ErrModelChecksum
+	}
+
+	return &header, payload, nil
+}
+
+// saveModel atomically replaces the model file at path. It writes to a temp
+// file in the same directory, syncs it to disk, checks that it reads back and
+// renames it over path, keeping up to generations previous files.
+func saveModel(path string, header *ModelHeader, payload []byte, generations int) (err error) {
+	dir, base := filepath.Split(path)
+	if len(dir) == 0 {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// temp files are private, give the model the usual or the previous mode.
+	var mode os.FileMode = 0o644
+	if info, e := os.Stat(path); e == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return err
+	}
+
+	if err = writeModel(tmp, header, payload); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if written, payload, e := readModel(tmp.Name()); e != nil {
+		return fmt.Errorf("verifying written model: %w", e)
+	} else if err = verifyPayload(written, payload); err != nil {
+		return fmt.Errorf("verifying written model: %w", err)
+	}
+
+	if err = keepGenerations(path, generations); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// keepGenerations shifts the previous model files of path by one and keeps
+// the current one as generation 1, dropping the oldest beyond generations.
+func keepGenerations(path string, generations int) error {
+	if generations <= 0 {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for i := generations - 1; i >= 1; i-- {
+		if err := os.Rename(GenerationPath(path, i), GenerationPath(path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// link instead of rename, so path exists until the new file replaces it.
+	previous := GenerationPath(path, 1)
+	os.Remove(previous)
+	if err := os.Link(path, previous); err == nil {
+		return nil
+	}
+
+	return copyFile(path, previous)
+}
+
+// verifyPayload restores the storages of a model file into scratch storages
+// without keeping them, the way loading the model does, so that a file that
+// decodes but doesn't load never replaces a model. The terms of the index
+// are not looked at, which makes the tokenizer of the scratch storages
+// irrelevant, the english one needs no files.
+func verifyPayload(header *ModelHeader, payload []byte) error {
+	_, _, _, err := restoreStorages(header, payload, Config{Tokenizer: TokenizerEnglish})
+	return err
+}
+
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// syncDir makes a rename in dir durable. Not all platforms support syncing
+// a directory, so failures to do so are ignored.
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	f.Sync()
+	return nil
+}
+
+// writeModel writes header and payload to writer, filling in the version,
+// the payload size and the checksum of header.
+func writeModel(writer io.Writer, header *ModelHeader, payload []byte) error {
+	header.Version = ModelFormatVersion
+	header.PayloadSize = int64(len(payload))
+	header.Checksum = checksum(payload)
+
+	var headerBytes bytes.Buffer
+	if err := gob.NewEncoder(&headerBytes).Encode(header); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(writer, modelMagic); err != nil {
+		return err
+	}
+	if err := binary.Write(writer, binary.BigEndian, uint32(headerBytes.Len())); err != nil {
+		return err
+	}
+	if _, err := writer.Write(headerBytes.Bytes()); err != nil {
+		return err
+	}
+
+	_, err := writer.Write(payload)
+	return err
+}
+
+func checksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveModelRejectsInconsistentPayload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Update("what is a gateway?", map[string]int{"a device": 1})
+	storage.BuildIndex()
+	if err := storage.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// statistics covering one key more than the index, the file decodes but
+	// doesn't load.
+	keys := []string{"what is a gateway?"}
+	responses := map[string]map[string]int{"what is a gateway?": {"a device": 1}}
+	indexes := map[string][]int{"gateway": {0}}
+	statistics := indexStatistics{DocLengths: []int{1, 1}, TotalLength: 2}
+	var payload bytes.Buffer
+	encoder := gob.NewEncoder(&payload)
+	for _, section := range []any{
+		keys, responses, indexes,
+		[]string(nil), map[string]map[string]int{}, map[string][]int{},
+		map[string][]string{}, map[string][]string{},
+		statistics, indexStatistics{},
+	} {
+		if err := encoder.Encode(section); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := saveModel(path, &ModelHeader{}, payload.Bytes(), 0); err == nil {
+		t.Fatal("saved a model that doesn't load")
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(current, saved) {
+		t.Fatal("the previous model was replaced")
+	}
+	if _, err := NewSeparatedMemoryStorage(path, Config{}); err != nil {
+		t.Fatalf("the previous model doesn't load: %v", err)
+	}
+}
+
+// syncVersions syncs storage once per version, each version storing one key
+// more than the previous one.
+func syncVersions(t *testing.T, storage *separatedMemoryStorage, from, to int) {
+	t.Helper()
+
+	for version := from; version <= to; version++ {
+		storage.Update(fmt.Sprintf("what is gateway %d?", version), map[string]int{"a device": 1})
+		if err := storage.Sync(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkVersions fails unless the model at path and its generations, in
+// order, are the versions written by syncVersions.
+func checkVersions(t *testing.T, path string, versions ...int) {
+	t.Helper()
+
+	for generation, version := range versions {
+		file := path
+		if generation > 0 {
+			file = GenerationPath(path, generation)
+		}
+		header, err := ReadModelHeader(file)
+		if err != nil {
+			t.Fatalf("generation %d: %v", generation, err)
+		}
+		if header.Keys != version {
+			t.Errorf("generation %d is version %d, want %d", generation, header.Keys, version)
+		}
+	}
+	if _, err := os.Stat(GenerationPath(path, len(versions))); !os.IsNotExist(err) {
+		t.Errorf("generation %d is kept: %v", len(versions), err)
+	}
+}
+
+func TestKeepGenerations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{Generations: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	syncVersions(t, storage, 1, 1)
+	checkVersions(t, path, 1)
+	syncVersions(t, storage, 2, 2)
+	checkVersions(t, path, 2, 1)
+	syncVersions(t, storage, 3, 4)
+	checkVersions(t, path, 4, 3, 2)
+
+	// without generations nothing is kept besides the model
+	path = filepath.Join(t.TempDir(), "model.gob")
+	storage, err = NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncVersions(t, storage, 1, 2)
+	checkVersions(t, path, 2)
+}
+
+func TestRollbackModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{Generations: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncVersions(t, storage, 1, 4)
+	checkVersions(t, path, 4, 3, 2)
+
+	// the replaced model becomes generation 1, the ones in between move up
+	if err := RollbackModel(path, 2); err != nil {
+		t.Fatal(err)
+	}
+	checkVersions(t, path, 2, 4, 3)
+	restored, err := NewSeparatedMemoryStorage(path, Config{Generations: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restored.Find("what is gateway 3?"); ok {
+		t.Fatal("the rolled back model has the keys of a later version")
+	}
+
+	// rolling back to 1 undoes it
+	if err := RollbackModel(path, 1); err != nil {
+		t.Fatal(err)
+	}
+	checkVersions(t, path, 4, 2, 3)
+
+	// the generations go on from the rolled back model
+	syncVersions(t, restored, 3, 3)
+	checkVersions(t, path, 3, 4, 2)
+
+	if err := RollbackModel(path, 3); err == nil {
+		t.Fatal("rolled back to a missing generation")
+	}
+	if err := os.WriteFile(GenerationPath(path, 2), []byte("not a model"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RollbackModel(path, 2); err == nil {
+		t.Fatal("rolled back to a broken generation")
+	}
+	header, err := ReadModelHeader(path)
+	if err != nil || header.Keys != 3 {
+		t.Fatalf("a failed rollback replaced the model: %+v, %v", header, err)
+	}
+}
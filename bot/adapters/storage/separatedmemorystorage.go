@@ -1,103 +1,389 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"sync"
+	"time"
 
 	"golangChatBot/bot/nlp"
 )
 
+// separatedMemoryStorage is safe for concurrent use, as long as both of its
+// storages are.
 type separatedMemoryStorage struct {
-	filepath           string
+	filepath    string
+	language    string
+	tokenizer   TokenizerConfig
+	generations int
+	lock        sync.RWMutex
+	syncLock    sync.Mutex
+	// moveLock is held for writing while a sentence moves between the
+	// storages, so that it is seen in exactly one of them.
+	moveLock           sync.RWMutex
+	corpora            map[string]string
 	declarativeStorage GobStorage
 	questionStorage    GobStorage
 }
 
 func NewSeparatedMemoryStorage(filepath string, config Config) (*separatedMemoryStorage, error) {
-	var declarativeStorage, questionStorage GobStorage
+	if _, err := os.Stat(filepath); err != nil {
+		return NewEmptySeparatedMemoryStorage(filepath, config)
+	}
+	if err := checkTokenizer(config); err != nil {
+		return nil, err
+	}
+	tokenizer := newTokenizerConfig(config)
 
-	if _, err := os.Stat(filepath); err == nil {
-		f, err := os.Open(filepath)
-		if err != nil {
-			return nil, err
-		}
-		defer f.Close()
+	header, payload, err := readModel(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath, err)
+	}
 
-		decoder := gob.NewDecoder(f)
-		if declarativeStorage, err = RestoreMemoryStorage(decoder, config); err != nil {
-			return nil, err
-		}
+	// the index only matches the terms of the tokenizer it was built with.
+	warnings, err := checkIndexedWith(header.Tokenizer, tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath, err)
+	}
+	for _, warning := range warnings {
+		log.Printf("%s: %s", filepath, warning)
+	}
 
-		if questionStorage, err = RestoreMemoryStorage(decoder, config); err != nil {
-			return nil, err
-		}
-	} else {
-		declarativeStorage = NewMemoryStorage(config)
-		questionStorage = NewMemoryStorage(config)
+	declarativeStorage, questionStorage, corpora, err := restoreStorages(header, payload, config)
+	if err != nil {
+		return nil, err
 	}
 
 	return &separatedMemoryStorage{
 		filepath:           filepath,
+		language:           config.Language,
+		tokenizer:          tokenizer,
+		generations:        config.Generations,
+		corpora:            corpora,
 		declarativeStorage: declarativeStorage,
 		questionStorage:    questionStorage,
 	}, nil
 }
 
+// NewEmptySeparatedMemoryStorage creates a storage saved to filepath without
+// loading the model there, to train it from scratch.
+func NewEmptySeparatedMemoryStorage(filepath string, config Config) (*separatedMemoryStorage, error) {
+	if err := checkTokenizer(config); err != nil {
+		return nil, err
+	}
+
+	return &separatedMemoryStorage{
+		filepath:           filepath,
+		language:           config.Language,
+		tokenizer:          newTokenizerConfig(config),
+		generations:        config.Generations,
+		corpora:            make(map[string]string),
+		declarativeStorage: NewMemoryStorage(config),
+		questionStorage:    NewMemoryStorage(config),
+	}, nil
+}
+
+// restoreStorages decodes the declarative and the question storage and the
+// corpora of a model file from its header and payload.
+func restoreStorages(header *ModelHeader, payload []byte, config Config) (declarative, question GobStorage, corpora map[string]string, err error) {
+	decoder := gob.NewDecoder(bytes.NewReader(payload))
+	if declarative, err = RestoreMemoryStorage(decoder, config); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if question, err = RestoreMemoryStorage(decoder, config); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// categories come after both storages to keep legacy models readable.
+	if err = declarative.RestoreCategories(decoder); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if err = question.RestoreCategories(decoder); err != nil {
+		return nil, nil, nil, err
+	}
+
+	corpora = make(map[string]string)
+	if header.Version == legacyModelFormatVersion {
+		// legacy models may end with the corpora, migrated into the header on Sync.
+		if err = decoder.Decode(&corpora); err != nil && !errors.Is(err, io.EOF) {
+			return nil, nil, nil, err
+		}
+	} else {
+		if err = declarative.RestoreStatistics(decoder); err != nil {
+			return nil, nil, nil, err
+		}
+
+		if err = question.RestoreStatistics(decoder); err != nil {
+			return nil, nil, nil, err
+		}
+
+		if header.Corpora != nil {
+			corpora = header.Corpora
+		}
+	}
+
+	return declarative, question, corpora, nil
+}
+
+func (storage *separatedMemoryStorage) AddCategory(sentence, category string) {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	if storage.isQuestion(sentence) {
+		storage.questionStorage.AddCategory(sentence, category)
+	} else {
+		storage.declarativeStorage.AddCategory(sentence, category)
+	}
+}
+
+func (storage *separatedMemoryStorage) AddCorpus(hash, file string) {
+	storage.lock.Lock()
+	defer storage.lock.Unlock()
+
+	storage.corpora[hash] = file
+}
+
 func (storage *separatedMemoryStorage) BuildIndex() {
 	storage.declarativeStorage.BuildIndex()
 	storage.questionStorage.BuildIndex()
 }
 
+func (storage *separatedMemoryStorage) Categories(sentence string) []string {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	primary, secondary := storage.route(sentence)
+	if categories := primary.Categories(sentence); len(categories) > 0 {
+		return categories
+	}
+
+	return secondary.Categories(sentence)
+}
+
+func (storage *separatedMemoryStorage) Compact() {
+	storage.declarativeStorage.Compact()
+	storage.questionStorage.Compact()
+}
+
 func (storage *separatedMemoryStorage) Count() int {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
 	return storage.declarativeStorage.Count() + storage.questionStorage.Count()
 }
 
+func (storage *separatedMemoryStorage) HasCorpus(hash string) bool {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	_, ok := storage.corpora[hash]
+	return ok
+}
+
 func (storage *separatedMemoryStorage) Find(sentence string) (map[string]int, bool) {
-	if nlp.IsQuestion(sentence) {
-		return storage.questionStorage.Find(sentence)
-	} else {
-		return storage.declarativeStorage.Find(sentence)
-	}
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	return storage.find(sentence)
 }
 
 func (storage *separatedMemoryStorage) Search(sentence string) []string {
-	if nlp.IsQuestion(sentence) {
-		return storage.questionStorage.Search(sentence)
-	} else {
-		return storage.declarativeStorage.Search(sentence)
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	primary, secondary := storage.route(sentence)
+	if result := primary.Search(sentence); len(result) > 0 {
+		return result
 	}
+
+	return secondary.Search(sentence)
 }
 
+// Remove removes sentence from both storages, models trained before English
+// questions were detected may keep questions in the declarative one.
 func (storage *separatedMemoryStorage) Remove(sentence string) {
-	if nlp.IsQuestion(sentence) {
-		storage.questionStorage.Remove(sentence)
-	} else {
-		storage.declarativeStorage.Remove(sentence)
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	storage.declarativeStorage.Remove(sentence)
+	storage.questionStorage.Remove(sentence)
+}
+
+// Rename moves sentence to newSentence, into the other storage if newSentence
+// is routed there, and indexes newSentence in place of sentence. No reader
+// sees the sentence in both storages or in neither.
+func (storage *separatedMemoryStorage) Rename(sentence, newSentence string) error {
+	storage.moveLock.Lock()
+	defer storage.moveLock.Unlock()
+
+	source := storage.locate(sentence)
+	target, _ := storage.route(newSentence)
+	if source == target {
+		return source.Rename(sentence, newSentence)
+	}
+
+	responses, ok := source.Find(sentence)
+	if !ok {
+		return fmt.Errorf("%q is not stored", sentence)
+	}
+	if _, ok := storage.find(newSentence); ok {
+		return fmt.Errorf("%q is already stored", newSentence)
+	}
+
+	categories := source.Categories(sentence)
+	target.Update(newSentence, responses)
+	for _, category := range categories {
+		target.AddCategory(newSentence, category)
 	}
+	source.Remove(sentence)
+
+	return nil
+}
+
+// ReplaceAnswer replaces the response answer to sentence with newAnswer.
+func (storage *separatedMemoryStorage) ReplaceAnswer(sentence, answer, newAnswer string) error {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	return storage.locate(sentence).ReplaceAnswer(sentence, answer, newAnswer)
 }
 
 func (storage *separatedMemoryStorage) Sync() error {
-	f, err := os.Create(storage.filepath)
-	if err != nil {
-		return err
+	storage.syncLock.Lock()
+	defer storage.syncLock.Unlock()
+
+	// each storage is copied at once, an update between the sections of one
+	// storage would give statistics that don't match its keys.
+	storage.moveLock.RLock()
+	declarative := storage.declarativeStorage.snapshot()
+	question := storage.questionStorage.snapshot()
+	storage.moveLock.RUnlock()
+
+	// categories and statistics come after both storages to keep legacy
+	// models readable.
+	sections := []any{
+		declarative.keys, declarative.responses, declarative.indexes,
+		question.keys, question.responses, question.indexes,
+		declarative.categories, question.categories,
+		declarative.statistics, question.statistics,
 	}
-	defer f.Close()
 
-	encoder := gob.NewEncoder(f)
+	var payload bytes.Buffer
+	encoder := gob.NewEncoder(&payload)
+	for _, section := range sections {
+		if err := encoder.Encode(section); err != nil {
+			return err
+		}
+	}
+
+	header := ModelHeader{
+		CreatedAt: time.Now(),
+		Corpora:   storage.Corpora(),
+		Tokenizer: storage.tokenizer,
+		Keys:      len(declarative.responses) + len(question.responses),
+	}
+
+	return saveModel(storage.filepath, &header, payload.Bytes(), storage.generations)
+}
+
+// TermIndex returns the index of the storage text is routed to, or of the
+// other one if none of the terms of text is indexed there.
+func (storage *separatedMemoryStorage) TermIndex(sentence string) TermIndex {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
 
-	storage.declarativeStorage.SetOutput(encoder)
-	if err := storage.declarativeStorage.Sync(); err != nil {
-		return err
+	primary, secondary := storage.route(sentence)
+	for _, term := range primary.Terms(sentence) {
+		if primary.DocumentFrequency(term) > 0 {
+			return primary
+		}
 	}
 
-	storage.questionStorage.SetOutput(encoder)
-	return storage.questionStorage.Sync()
+	return secondary
 }
 
 func (storage *separatedMemoryStorage) Update(sentence string, responses map[string]int) {
-	if nlp.IsQuestion(sentence) {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	if storage.isQuestion(sentence) {
 		storage.questionStorage.Update(sentence, responses)
 	} else {
 		storage.declarativeStorage.Update(sentence, responses)
 	}
 }
+
+// TokenSet returns the token set of key from the storage keeping it.
+func (storage *separatedMemoryStorage) TokenSet(key string) (TokenSet, bool) {
+	storage.moveLock.RLock()
+	defer storage.moveLock.RUnlock()
+
+	primary, secondary := storage.route(key)
+	if set, ok := primary.TokenSet(key); ok {
+		return set, true
+	}
+
+	return secondary.TokenSet(key)
+}
+
+// Corpora returns the files the storage was trained with by content hash.
+func (storage *separatedMemoryStorage) Corpora() map[string]string {
+	storage.lock.RLock()
+	defer storage.lock.RUnlock()
+
+	corpora := make(map[string]string, len(storage.corpora))
+	for hash, file := range storage.corpora {
+		corpora[hash] = file
+	}
+
+	return corpora
+}
+
+// find looks sentence up in both storages, the moveLock must be held.
+func (storage *separatedMemoryStorage) find(sentence string) (map[string]int, bool) {
+	primary, secondary := storage.route(sentence)
+	if responses, ok := primary.Find(sentence); ok {
+		return responses, true
+	}
+
+	// models trained before English questions were detected keep them all
+	// in the declarative storage, so look into the other one as well.
+	return secondary.Find(sentence)
+}
+
+func (storage *separatedMemoryStorage) isQuestion(sentence string) bool {
+	return nlp.IsQuestionIn(storage.language, sentence)
+}
+
+// locate returns the storage that keeps sentence, the one it is routed to if
+// neither does.
+func (storage *separatedMemoryStorage) locate(sentence string) GobStorage {
+	primary, secondary := storage.route(sentence)
+	if _, ok := primary.Find(sentence); !ok {
+		if _, ok := secondary.Find(sentence); ok {
+			return secondary
+		}
+	}
+
+	return primary
+}
+
+func (storage *separatedMemoryStorage) route(sentence string) (primary, secondary GobStorage) {
+	if storage.isQuestion(sentence) {
+		return storage.questionStorage, storage.declarativeStorage
+	}
+
+	return storage.declarativeStorage, storage.questionStorage
+}
+
+func (storage *separatedMemoryStorage) UpdateIndex() {
+	storage.declarativeStorage.UpdateIndex()
+	storage.questionStorage.UpdateIndex()
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSyncWhileUpdating(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		storage.Update(fmt.Sprintf("what is gateway %d?", i), map[string]int{"an answer": 1})
+	}
+	storage.BuildIndex()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 2000; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			storage.Update(fmt.Sprintf("how is sensor %d wired?", i), map[string]int{"another answer": 1})
+			storage.UpdateIndex()
+		}
+	}()
+
+	for i := 0; i < 30; i++ {
+		if err := storage.Sync(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewSeparatedMemoryStorage(path, Config{}); err != nil {
+			close(done)
+			wg.Wait()
+			t.Fatalf("sync %d wrote a model that doesn't load: %v", i, err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestUpdateIndexesNewKeysSeparated(t *testing.T) {
+	storage, err := NewEmptySeparatedMemoryStorage(filepath.Join(t.TempDir(), "model.gob"), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Update("what is gateway 1?", map[string]int{"an answer": 1})
+	storage.BuildIndex()
+
+	storage.Update("is the router waterproof?", map[string]int{"It is IP65.": 1})
+	storage.Update("The router is waterproof.", map[string]int{"Indeed.": 1})
+	for query, key := range map[string]string{
+		"router waterproof?":       "is the router waterproof?",
+		"the router is waterproof": "The router is waterproof.",
+	} {
+		if result := storage.Search(query); !slices.Contains(result, key) {
+			t.Errorf("Search(%q) = %q, the updated key is not found", query, result)
+		}
+	}
+}
+
+func TestRenameAcrossStorages(t *testing.T) {
+	storage, err := NewEmptySeparatedMemoryStorage(filepath.Join(t.TempDir(), "model.gob"), Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	question, statement := "is the router waterproof?", "The router is waterproof."
+	storage.Update(question, map[string]int{"It is IP65.": 1})
+	storage.AddCategory(question, "hardware")
+	storage.BuildIndex()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	failure := make(chan string, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if count := storage.Count(); count != 1 {
+				select {
+				case failure <- fmt.Sprintf("Count() = %d while renaming", count):
+				default:
+				}
+			}
+		}
+	}()
+
+	for i := 0; i < 500; i++ {
+		from, to := question, statement
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		if err := storage.Rename(from, to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	select {
+	case message := <-failure:
+		t.Fatal(message)
+	default:
+	}
+
+	if err := storage.Rename(question, statement); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := storage.questionStorage.Find(question); ok {
+		t.Fatalf("%q is still in the question storage", question)
+	}
+	if result := storage.Search("the router is waterproof"); !slices.Contains(result, statement) {
+		t.Fatalf("Search found %q", result)
+	}
+	if categories := storage.Categories(statement); !slices.Equal(categories, []string{"hardware"}) {
+		t.Fatalf("Categories(%q) = %q", statement, categories)
+	}
+}
+
+func TestPlainTokenizerModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Update("What is the periMICA?", map[string]int{"An edge device.": 1})
+	storage.Update("How do I wire a periNODE 0-10V?", map[string]int{"With two wires.": 1})
+	storage.BuildIndex()
+	if err := storage.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := restored.Search("how to wire the periNODE 0-10V?"); !slices.Contains(result, "How do I wire a periNODE 0-10V?") {
+		t.Fatalf("Search found %q", result)
+	}
+	if responses, ok := restored.Find("What is the periMICA?"); !ok || responses["An edge device."] != 1 {
+		t.Fatalf("Find returned %v, %t", responses, ok)
+	}
+
+	dir := t.TempDir()
+	dictFile, idfFile := filepath.Join(dir, "dict.txt"), filepath.Join(dir, "idf.txt")
+	for _, file := range []string{dictFile, idfFile} {
+		if err := os.WriteFile(file, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, config := range []Config{
+		{Tokenizer: TokenizerEnglish},
+		{DictFile: dictFile, IdfFile: idfFile},
+	} {
+		if _, err := NewSeparatedMemoryStorage(path, config); err == nil {
+			t.Errorf("the model indexed without dictionaries loads with %+v", config)
+		}
+	}
+}
+
+func TestTokenSetsSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.gob")
+	storage, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"What is the periMICA?", "The periMICA is an edge device.", "Is the periMICA the periMICA?"}
+	for _, key := range keys {
+		storage.Update(key, map[string]int{"An answer.": 1})
+	}
+	storage.BuildIndex()
+	storage.Rename("What is the periMICA?", "What is the periNODE?")
+	storage.Compact()
+	if err := storage.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewSeparatedMemoryStorage(path, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"What is the periNODE?", "The periMICA is an edge device.", "Is the periMICA the periMICA?"} {
+		set, ok := restored.TokenSet(key)
+		if !ok || !reflect.DeepEqual(set, NewTokenSet(key)) {
+			t.Errorf("TokenSet(%q) = %v, %t, want %v", key, set, ok, NewTokenSet(key))
+		}
+	}
+	if _, ok := restored.TokenSet("What is the periMICA?"); ok {
+		t.Error("the renamed key has a token set")
+	}
+}
+
+func TestCheckIndexedWith(t *testing.T) {
+	plain := TokenizerConfig{Name: TokenizerJieba, StopWordsFile: "stop_words.txt", Language: "en"}
+	dictionaries := TokenizerConfig{Name: TokenizerJieba, DictFile: "dict.txt", IdfFile: "idf.txt", Language: "en"}
+	english := TokenizerConfig{Name: TokenizerEnglish, Language: "en"}
+
+	tests := []struct {
+		indexed, current TokenizerConfig
+		warnings         int
+		fails            bool
+	}{
+		{plain, plain, 0, false},
+		{dictionaries, dictionaries, 0, false},
+		{plain, dictionaries, 0, true},
+		{dictionaries, plain, 0, true},
+		{plain, english, 0, true},
+		{english, plain, 0, true},
+		{dictionaries, TokenizerConfig{Name: TokenizerJieba, DictFile: "etc/dict.txt", IdfFile: "etc/idf.txt", Language: "en"}, 2, false},
+		{plain, TokenizerConfig{Name: TokenizerJieba, Language: "de"}, 2, false},
+		// models indexed before the tokenizer was recorded
+		{TokenizerConfig{}, dictionaries, 0, false},
+		{TokenizerConfig{}, plain, 1, false},
+		{TokenizerConfig{}, english, 0, true},
+	}
+
+	for _, test := range tests {
+		warnings, err := checkIndexedWith(test.indexed, test.current)
+		if (err != nil) != test.fails || len(warnings) != test.warnings {
+			t.Errorf("checkIndexedWith(%+v, %+v) = %q, %v, want %d warnings and failing %t",
+				test.indexed, test.current, warnings, err, test.warnings, test.fails)
+		}
+	}
+}
@@ -1,11 +1,68 @@
 package storage
 
 type StorageAdapter interface {
+	AddCategory(string, string)
 	BuildIndex()
+	Categories(string) []string
+	Compact()
 	Count() int
 	Find(string) (map[string]int, bool)
 	Search(string) []string
 	Remove(string)
+	Rename(string, string) error
+	ReplaceAnswer(string, string, string) error
 	Sync() error
 	Update(string, map[string]int)
+	UpdateIndex()
+}
+
+// CorpusRegistry records which corpus files, by content hash, a storage was
+// trained with.
+type CorpusRegistry interface {
+	AddCorpus(hash, file string)
+	HasCorpus(hash string) bool
+	// Corpora returns the files by content hash.
+	Corpora() map[string]string
+}
+
+// TermIndex is the inverted index of a storage, with the statistics needed to
+// rank the keys by relevance.
+type TermIndex interface {
+	// Terms splits text into index terms, the same way the keys were split.
+	Terms(text string) []string
+	// Postings returns the keys indexed under term.
+	Postings(term string) []Posting
+	// DocumentFrequency returns the number of keys indexed under term.
+	DocumentFrequency(term string) int
+	// Documents returns the number of indexed keys.
+	Documents() int
+	// AverageLength returns the average number of terms of the indexed keys.
+	AverageLength() float64
+}
+
+// Posting is a key in the postings of a term, with its number of terms.
+type Posting struct {
+	Key    string
+	Length int
+}
+
+// TokenSet holds the distinct lowercased words of a text in the order they
+// first occur, with the number of times each of them occurs.
+type TokenSet struct {
+	Tokens []string
+	Counts []int
+}
+
+// TokenSetIndex keeps the token sets of the indexed keys, built when they
+// are indexed and saved with the model.
+type TokenSetIndex interface {
+	// TokenSet returns the token set of key, false if key is not indexed.
+	TokenSet(key string) (TokenSet, bool)
+}
+
+// IndexedStorage is a storage whose inverted index can be ranked over.
+type IndexedStorage interface {
+	StorageAdapter
+	// TermIndex returns the index to rank the keys matching text in.
+	TermIndex(text string) TermIndex
 }
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/wangbin/jiebago"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"golangChatBot/bot/nlp"
+)
+
+const (
+	// TokenizerJieba indexes the keywords of long texts and the words of
+	// short ones with jiebago. Without the dict and idf files it falls back
+	// to the built-in word splitting, which gives the same terms for English.
+	TokenizerJieba = "jieba"
+	// TokenizerEnglish indexes the stemmed words of English texts, without
+	// the stop words.
+	TokenizerEnglish = "english"
+)
+
+type (
+	// fallbackTokenizer can split a text a second way, for the texts whose
+	// terms found no keys.
+	fallbackTokenizer interface {
+		nlp.Tokenizer
+		Words(text string) []string
+	}
+
+	jiebaTokenizer struct {
+		segmenter *jiebago.Segmenter
+		extracter *nlp.TFIDFExtracter
+	}
+
+	// plainTokenizer splits texts the way jiebago does for English without
+	// any dictionary: words keep their case, Han characters stand alone and
+	// the keywords of long texts are their most frequent words.
+	plainTokenizer struct {
+		stopWords map[string]bool
+	}
+
+	wordAndCount struct {
+		word  string
+		count int
+	}
+)
+
+// tokenizerName returns the tokenizer config asks for, jieba by default.
+func tokenizerName(config Config) string {
+	if len(config.Tokenizer) == 0 {
+		return TokenizerJieba
+	}
+
+	return strings.ToLower(config.Tokenizer)
+}
+
+// checkTokenizer returns an error if the tokenizer of config is unknown or
+// the files it needs are missing.
+func checkTokenizer(config Config) error {
+	name := tokenizerName(config)
+	if name != TokenizerJieba && name != TokenizerEnglish {
+		return fmt.Errorf("unknown tokenizer %q, use %s or %s", config.Tokenizer, TokenizerJieba, TokenizerEnglish)
+	}
+
+	files := []string{config.StopWordsFile}
+	if name == TokenizerJieba {
+		if (len(config.DictFile) == 0) != (len(config.IdfFile) == 0) {
+			return fmt.Errorf("the jieba tokenizer needs both dict_file and idf_file, or neither of them")
+		}
+		files = append(files, config.DictFile, config.IdfFile)
+	}
+	for _, file := range files {
+		if len(file) == 0 {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("%s tokenizer: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func newTokenizer(config Config) nlp.Tokenizer {
+	switch name := tokenizerName(config); name {
+	case TokenizerEnglish:
+		var stopWords []string
+		if len(config.StopWordsFile) > 0 {
+			words, err := nlp.ReadWords(config.StopWordsFile)
+			logx.Must(err)
+			stopWords = words
+		}
+		return nlp.NewEnglishTokenizer(stopWords...)
+	case TokenizerJieba:
+		if len(config.DictFile) == 0 || len(config.IdfFile) == 0 {
+			return newPlainTokenizer(config)
+		}
+
+		// the dictionaries are shared by all storages, they are parsed once.
+		segmenter, err := nlp.Segmenter(config.DictFile)
+		logx.Must(err)
+		extracter, err := nlp.KeywordExtracter(config.DictFile, config.IdfFile, config.StopWordsFile)
+		logx.Must(err)
+		return &jiebaTokenizer{
+			segmenter: segmenter,
+			extracter: extracter,
+		}
+	default:
+		logx.Must(fmt.Errorf("unknown tokenizer %q, use %s or %s", name, TokenizerJieba, TokenizerEnglish))
+		return nil
+	}
+}
+
+// Tokenize returns the top keywords of long texts and the words of short ones.
+func (tokenizer *jiebaTokenizer) Tokenize(text string) []string {
+	if len([]rune(text)) <= thresholdForKeywords {
+		return tokenizer.Words(text)
+	}
+
+	return tokenizer.extracter.ExtractKeywords(text, topKeywords)
+}
+
+func (tokenizer *jiebaTokenizer) Words(text string) []string {
+	var words []string
+	for word := range tokenizer.segmenter.Cut(text, true) {
+		words = append(words, word)
+	}
+
+	return words
+}
+
+func newPlainTokenizer(config Config) *plainTokenizer {
+	stopWords := make(map[string]bool)
+	if len(config.StopWordsFile) > 0 {
+		words, err := nlp.ReadWords(config.StopWordsFile)
+		logx.Must(err)
+		for _, word := range words {
+			stopWords[word] = true
+		}
+	}
+
+	return &plainTokenizer{
+		stopWords: stopWords,
+	}
+}
+
+// Tokenize returns the most frequent words of long texts and the words of
+// short ones. Like the keywords of jiebago, they are at least 2 runes long
+// and not stop words.
+func (tokenizer *plainTokenizer) Tokenize(text string) []string {
+	if len([]rune(text)) <= thresholdForKeywords {
+		return tokenizer.Words(text)
+	}
+
+	counts := make(map[string]int)
+	for _, word := range tokenizer.Words(text) {
+		if len([]rune(word)) < 2 || tokenizer.stopWords[word] {
+			continue
+		}
+		counts[word]++
+	}
+
+	words := make([]wordAndCount, 0, len(counts))
+	for word, count := range counts {
+		words = append(words, wordAndCount{
+			word:  word,
+			count: count,
+		})
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].count != words[j].count {
+			return words[i].count > words[j].count
+		}
+		return words[i].word > words[j].word
+	})
+
+	var terms []string
+	for i := 0; i < len(words) && i < topKeywords; i++ {
+		terms = append(terms, words[i].word)
+	}
+
+	return terms
+}
+
+// Words splits text into runs of letters and digits, with the '#', '&', '.'
+// and '_' that jiebago keeps inside words, and single Han characters.
+func (tokenizer *plainTokenizer) Words(text string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.Trim(string(word), "#&._"))
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("#&._", r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	filtered := words[:0]
+	for _, word := range words {
+		if len(word) > 0 {
+			filtered = append(filtered, word)
+		}
+	}
+
+	return filtered
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPlainTokenizer(t *testing.T) {
+	tokenizer := newPlainTokenizer(Config{})
+	tokenizer.stopWords["the"] = true
+
+	words := []struct {
+		text  string
+		words []string
+	}{
+		{"", nil},
+		{"periNODE 0-10V", []string{"periNODE", "0", "10V"}},
+		{"IP 192.168.0.1, C# & _id_", []string{"IP", "192.168.0.1", "C", "id"}},
+		{"你好 periMICA", []string{"你", "好", "periMICA"}},
+	}
+	for _, test := range words {
+		if got := tokenizer.Words(test.text); !slices.Equal(got, test.words) {
+			t.Errorf("Words(%q) = %q, want %q", test.text, got, test.words)
+		}
+	}
+
+	terms := []struct {
+		text  string
+		terms []string
+	}{
+		// short texts keep all their words
+		{"a b", []string{"a", "b"}},
+		// long ones their most frequent words, no stop words nor single runes
+		{"the gateway forwards the data of a gateway", []string{"gateway", "of", "forwards", "data"}},
+		{"a b c d e f g h", nil},
+	}
+	for _, test := range terms {
+		if got := tokenizer.Tokenize(test.text); !slices.Equal(got, test.terms) {
+			t.Errorf("Tokenize(%q) = %q, want %q", test.text, got, test.terms)
+		}
+	}
+}
+
+func TestEnglishTokenizerStems(t *testing.T) {
+	storage := NewMemoryStorage(Config{Tokenizer: TokenizerEnglish})
+	storage.Update("What are the operating temperatures?", map[string]int{"-40°C to 85°C.": 1})
+	storage.Update("Which temperature does the sensor measure?", map[string]int{"The air temperature.": 1})
+	storage.Update("How is the sensor wired?", map[string]int{"With two wires.": 1})
+	storage.BuildIndex()
+
+	var keys []string
+	for _, posting := range storage.Postings("temperatur") {
+		keys = append(keys, posting.Key)
+	}
+	slices.Sort(keys)
+	want := []string{"What are the operating temperatures?", "Which temperature does the sensor measure?"}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("the postings of %q are %q, want %q", "temperatur", keys, want)
+	}
+	if df := storage.DocumentFrequency("temperatur"); df != 2 {
+		t.Fatalf("DocumentFrequency(%q) = %d, want 2", "temperatur", df)
+	}
+	for _, query := range []string{"temperature", "temperatures"} {
+		if result := storage.Search(query); len(result) != 2 {
+			t.Errorf("Search(%q) = %q, want both temperature questions", query, result)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package bot
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"golangChatBot/bot/adapters/input"
@@ -20,6 +24,35 @@ type ChatBot struct {
 	OutputAdapter  output.OutputAdapter
 	StorageAdapter storage.StorageAdapter
 	Trainer        Trainer
+	// Keywords are matched against questions to detect context categories.
+	Keywords []string
+	// MinConfidence is the confidence an answer needs for Respond to return it.
+	MinConfidence float32
+	// Fallbacks are tried in order when Respond has no confident answer.
+	Fallbacks []Fallback
+}
+
+// LoadKeywords reads the Keywords of a ChatBot from filename, one per line.
+func LoadKeywords(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keywords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			keywords = append(keywords, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return keywords, nil
 }
 
 func (chatbot *ChatBot) Train(data interface{}) error {
@@ -47,9 +80,72 @@ func (chatbot *ChatBot) Train(data interface{}) error {
 	}
 }
 
-func (chatbot *ChatBot) GetResponse(text string) []logic.Answer {
+func (chatbot *ChatBot) GetResponse(text string, opts ...logic.ProcessOption) []logic.Answer {
 	if chatbot.LogicAdapter.CanProcess(text) {
-		return chatbot.LogicAdapter.Process(text)
+		return chatbot.LogicAdapter.Process(text, opts...)
+	}
+
+	return nil
+}
+
+// Respond answers text within the given session, carrying the active context
+// categories over from earlier turns and recording the turn in the session.
+func (chatbot *ChatBot) Respond(ctx context.Context, session *Session, text string) ([]logic.Answer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// the session is only locked while its state is read and written, not
+	// while the question is answered.
+	session.lock.Lock()
+	settings := session.Settings
+	var opts []logic.ProcessOption
+	if settings.ContextEnabled {
+		session.touchCategories(chatbot.Keywords, text)
+		if categories := session.activeCategories(); len(categories) > 0 {
+			opts = append(opts, session.scope(categories))
+		}
+	}
+	session.lock.Unlock()
+
+	answers := chatbot.confident(text, chatbot.GetResponse(text, opts...))
+	if tops := settings.Tops; tops > 0 && len(answers) > tops {
+		answers = answers[:tops]
+	}
+
+	now := time.Now()
+	session.lock.Lock()
+	session.record(Turn{
+		Question: text,
+		Answers:  answers,
+		Time:     now,
+	})
+	if settings.ContextEnabled {
+		session.ageCategories()
+	}
+	session.lock.Unlock()
+	session.touch(now)
+
+	return answers, nil
+}
+
+// confident drops the answers below MinConfidence. If none is left, it returns
+// the reply of the first fallback that has one, or nothing.
+func (chatbot *ChatBot) confident(text string, answers []logic.Answer) []logic.Answer {
+	var result []logic.Answer
+	for _, answer := range answers {
+		if answer.Confidence >= chatbot.MinConfidence {
+			result = append(result, answer)
+		}
+	}
+	if len(result) > 0 {
+		return result
+	}
+
+	for _, fallback := range chatbot.Fallbacks {
+		if answer, ok := fallback.Reply(text, answers); ok {
+			return []logic.Answer{answer}
+		}
 	}
 
 	return nil
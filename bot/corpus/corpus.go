@@ -1,6 +1,8 @@
 package corpus
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -15,6 +17,17 @@ type Corpus struct {
 	Conversations [][]string `json:"conversations"`
 }
 
+// Hash returns the hex encoded SHA-256 of the content of file.
+func Hash(file string) (string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func LoadCorpora(filePaths []string) (map[string][][]string, error) {
 	result := make(map[string][][]string)
 
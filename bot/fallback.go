@@ -0,0 +1,200 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golangChatBot/bot/adapters/logic"
+)
+
+const (
+	// NoAnswerReply is the reply when there is neither a confident answer nor
+	// a fallback reply.
+	NoAnswerReply = "Hi there, no answer found at the moment. We'll update the developers regarding the question asked."
+
+	FallbackClarify    = "clarify"
+	FallbackSuggest    = "suggest"
+	FallbackUnanswered = "unanswered"
+
+	defaultClarificationPrompt = "I'm not sure I understood the question. Could you rephrase it or give more details?"
+	defaultSuggestions         = 3
+)
+
+type (
+	// Fallback replies to a question no answer was confident enough for.
+	// answers are the ones below the threshold, best first. A fallback
+	// returns false to leave the question to the next one of the chain.
+	Fallback interface {
+		Reply(question string, answers []logic.Answer) (logic.Answer, bool)
+	}
+
+	// FallbackConfig configures the fallbacks built by NewFallbacks.
+	FallbackConfig struct {
+		// Chain names the fallbacks in the order they are tried, one of
+		// clarify, suggest or unanswered.
+		Chain []string
+		// ClarificationPrompt is the reply of clarify, empty means the default.
+		ClarificationPrompt string
+		// Suggestions is the number of related questions suggest offers,
+		// 0 means the default of 3.
+		Suggestions int
+		// SuggestionConfidence is the confidence an answer needs for suggest
+		// to offer its question.
+		SuggestionConfidence float32
+		// UnansweredFile is the file unanswered appends the questions to as
+		// JSON lines, empty means they are not recorded.
+		UnansweredFile string
+		// UnansweredReply is the reply of unanswered, empty means NoAnswerReply.
+		UnansweredReply string
+	}
+
+	// UnansweredQuestion is a line of the unanswered questions file.
+	UnansweredQuestion struct {
+		Time       time.Time `json:"time"`
+		Question   string    `json:"question"`
+		Best       string    `json:"best,omitempty"`
+		Confidence float32   `json:"confidence"`
+	}
+
+	clarifyFallback struct {
+		prompt string
+	}
+
+	suggestFallback struct {
+		suggestions   int
+		minConfidence float32
+	}
+
+	unansweredFallback struct {
+		file  string
+		reply string
+		lock  sync.Mutex
+	}
+)
+
+// NewFallbacks builds the fallback chain described by config.
+func NewFallbacks(config FallbackConfig) ([]Fallback, error) {
+	var fallbacks []Fallback
+	for _, name := range config.Chain {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case FallbackClarify:
+			prompt := config.ClarificationPrompt
+			if len(prompt) == 0 {
+				prompt = defaultClarificationPrompt
+			}
+			fallbacks = append(fallbacks, &clarifyFallback{prompt: prompt})
+		case FallbackSuggest:
+			suggestions := config.Suggestions
+			if suggestions <= 0 {
+				suggestions = defaultSuggestions
+			}
+			fallbacks = append(fallbacks, &suggestFallback{
+				suggestions:   suggestions,
+				minConfidence: config.SuggestionConfidence,
+			})
+		case FallbackUnanswered:
+			reply := config.UnansweredReply
+			if len(reply) == 0 {
+				reply = NoAnswerReply
+			}
+			fallbacks = append(fallbacks, &unansweredFallback{
+				file:  config.UnansweredFile,
+				reply: reply,
+			})
+		default:
+			return nil, fmt.Errorf("unknown fallback %q, use %s, %s or %s",
+				name, FallbackClarify, FallbackSuggest, FallbackUnanswered)
+		}
+	}
+
+	return fallbacks, nil
+}
+
+func (fallback *clarifyFallback) Reply(question string, answers []logic.Answer) (logic.Answer, bool) {
+	return logic.Answer{
+		Content: fallback.prompt,
+		Adapter: FallbackClarify,
+	}, true
+}
+
+// Reply offers the stored questions of the best answers, if any of them is
+// confident enough.
+func (fallback *suggestFallback) Reply(question string, answers []logic.Answer) (logic.Answer, bool) {
+	var suggestions []string
+	seen := make(map[string]bool)
+	for _, answer := range answers {
+		if len(answer.Question) == 0 || seen[answer.Question] || answer.Confidence < fallback.minConfidence {
+			continue
+		}
+
+		seen[answer.Question] = true
+		suggestions = append(suggestions, answer.Question)
+		if len(suggestions) == fallback.suggestions {
+			break
+		}
+	}
+	if len(suggestions) == 0 {
+		return logic.Answer{}, false
+	}
+
+	var builder strings.Builder
+	builder.WriteString("I'm not sure I understood the question. Did you mean:")
+	for _, suggestion := range suggestions {
+		builder.WriteString("\n- ")
+		builder.WriteString(suggestion)
+	}
+
+	return logic.Answer{
+		Content: builder.String(),
+		Adapter: FallbackSuggest,
+	}, true
+}
+
+// Reply records the question and always replies, a failure to record it
+// doesn't keep the user waiting for an answer.
+func (fallback *unansweredFallback) Reply(question string, answers []logic.Answer) (logic.Answer, bool) {
+	if len(fallback.file) > 0 {
+		record := UnansweredQuestion{
+			Time:     time.Now(),
+			Question: question,
+		}
+		if len(answers) > 0 {
+			record.Best = answers[0].Question
+			record.Confidence = answers[0].Confidence
+		}
+		if err := fallback.record(record); err != nil {
+			fmt.Fprintf(os.Stderr, "Error recording unanswered question: %v\n", err)
+		}
+	}
+
+	return logic.Answer{
+		Content: fallback.reply,
+		Adapter: FallbackUnanswered,
+	}, true
+}
+
+func (fallback *unansweredFallback) record(record UnansweredQuestion) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	fallback.lock.Lock()
+	defer fallback.lock.Unlock()
+
+	file, err := os.OpenFile(fallback.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golangChatBot/bot/adapters/logic"
+)
+
+// fixedAdapter returns the same answers to every question.
+type fixedAdapter struct {
+	answers []logic.Answer
+}
+
+func (adapter *fixedAdapter) CanProcess(string) bool {
+	return true
+}
+
+func (adapter *fixedAdapter) Process(string, ...logic.ProcessOption) []logic.Answer {
+	return adapter.answers
+}
+
+func (adapter *fixedAdapter) SetVerbose() {
+}
+
+func newFallbacks(t *testing.T, config FallbackConfig) []Fallback {
+	t.Helper()
+
+	fallbacks, err := NewFallbacks(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fallbacks
+}
+
+func TestRespondDropsUnconfidentAnswers(t *testing.T) {
+	chatbot := &ChatBot{
+		LogicAdapter: &fixedAdapter{answers: []logic.Answer{
+			{Content: "sure", Confidence: 0.9, Question: "q1"},
+			{Content: "borderline", Confidence: 0.5, Question: "q2"},
+			{Content: "unsure", Confidence: 0.3, Question: "q3"},
+		}},
+		MinConfidence: 0.5,
+	}
+	session := NewSessionStore(DefaultSessionSettings(), time.Minute).Get("a")
+
+	answers, err := chatbot.Respond(context.Background(), session, "question")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 2 || answers[0].Content != "sure" || answers[1].Content != "borderline" {
+		t.Fatalf("got %+v, want the answers of a confidence of at least 0.5", answers)
+	}
+
+	// without a fallback nothing is left of unsure answers
+	chatbot.MinConfidence = 0.95
+	if answers, _ := chatbot.Respond(context.Background(), session, "question"); len(answers) != 0 {
+		t.Fatalf("got %+v, want no answers", answers)
+	}
+}
+
+func TestFallbackChain(t *testing.T) {
+	chatbot := &ChatBot{
+		MinConfidence: 0.8,
+		Fallbacks: newFallbacks(t, FallbackConfig{
+			Chain:                []string{"suggest", " Unanswered "},
+			SuggestionConfidence: 0.4,
+		}),
+	}
+
+	// suggest declines when no answer is good enough to offer
+	answers := chatbot.confident("question", []logic.Answer{{Content: "a", Confidence: 0.2, Question: "q1"}})
+	if len(answers) != 1 || answers[0].Adapter != FallbackUnanswered || answers[0].Content != NoAnswerReply {
+		t.Fatalf("got %+v, want the reply of unanswered", answers)
+	}
+
+	answers = chatbot.confident("question", []logic.Answer{{Content: "a", Confidence: 0.5, Question: "q1"}})
+	if len(answers) != 1 || answers[0].Adapter != FallbackSuggest {
+		t.Fatalf("got %+v, want the reply of suggest", answers)
+	}
+
+	// a confident answer leaves the fallbacks out
+	answers = chatbot.confident("question", []logic.Answer{{Content: "a", Confidence: 0.9, Question: "q1"}})
+	if len(answers) != 1 || answers[0].Content != "a" {
+		t.Fatalf("got %+v, want the confident answer", answers)
+	}
+
+	chatbot.Fallbacks = newFallbacks(t, FallbackConfig{
+		Chain:               []string{"clarify", "unanswered"},
+		ClarificationPrompt: "Could you rephrase it?",
+	})
+	answers = chatbot.confident("question", nil)
+	if len(answers) != 1 || answers[0].Adapter != FallbackClarify || answers[0].Content != "Could you rephrase it?" {
+		t.Fatalf("got %+v, want the reply of clarify", answers)
+	}
+}
+
+func TestSuggestFallback(t *testing.T) {
+	fallback := newFallbacks(t, FallbackConfig{
+		Chain:                []string{"suggest"},
+		Suggestions:          2,
+		SuggestionConfidence: 0.3,
+	})[0]
+
+	answer, ok := fallback.Reply("question", []logic.Answer{
+		{Content: "a", Confidence: 0.7, Question: "What is the periMICA?"},
+		{Content: "b", Confidence: 0.6, Question: "What is the periMICA?"},
+		{Content: "c", Confidence: 0.6},
+		{Content: "d", Confidence: 0.5, Question: "What is the periNODE?"},
+		{Content: "e", Confidence: 0.4, Question: "What is the periCORE?"},
+	})
+	if !ok {
+		t.Fatal("suggest declined")
+	}
+	want := "I'm not sure I understood the question. Did you mean:\n- What is the periMICA?\n- What is the periNODE?"
+	if answer.Content != want {
+		t.Fatalf("got %q, want %q", answer.Content, want)
+	}
+
+	if _, ok := fallback.Reply("question", []logic.Answer{{Content: "a", Confidence: 0.2, Question: "q"}}); ok {
+		t.Fatal("suggest offers a question below SuggestionConfidence")
+	}
+}
+
+func TestUnansweredFallbackRecords(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "unanswered.jsonl")
+	fallback := newFallbacks(t, FallbackConfig{
+		Chain:           []string{"unanswered"},
+		UnansweredFile:  file,
+		UnansweredReply: "We'll get back to you.",
+	})[0]
+
+	answer, ok := fallback.Reply("how do I fly the gateway", []logic.Answer{
+		{Content: "a", Confidence: 0.25, Question: "how do I reset the gateway"},
+		{Content: "b", Confidence: 0.1, Question: "how do I wire the gateway"},
+	})
+	if !ok || answer.Content != "We'll get back to you." {
+		t.Fatalf("got %+v, %t", answer, ok)
+	}
+	if _, ok := fallback.Reply("hello?", nil); !ok {
+		t.Fatal("unanswered declined")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []UnansweredQuestion
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record UnansweredQuestion
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	first := records[0]
+	if first.Question != "how do I fly the gateway" || first.Best != "how do I reset the gateway" ||
+		first.Confidence != 0.25 || first.Time.IsZero() {
+		t.Errorf("got the record %+v", first)
+	}
+	if second := records[1]; second.Question != "hello?" || second.Best != "" || second.Confidence != 0 {
+		t.Errorf("got the record %+v", second)
+	}
+}
+
+func TestNewFallbacksUnknownName(t *testing.T) {
+	_, err := NewFallbacks(FallbackConfig{Chain: []string{"suggest", "escalate"}})
+	if err == nil {
+		t.Fatal("NewFallbacks accepts an unknown fallback")
+	}
+	if !strings.Contains(err.Error(), `"escalate"`) {
+		t.Fatalf("the error %q doesn't name the fallback", err)
+	}
+}
@@ -1,5 +1,11 @@
 package nlp
 
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
 const (
 	Ins = iota
 	Del
@@ -43,14 +49,113 @@ func (operation EditOperation) String() string {
 }
 
 func SimilarityForStrings(source, target string) float32 {
-	distance := DistanceForStrings([]rune(source), []rune(target), DefaultOptions)
-	total := len([]rune(source)) + len([]rune(target))
+	sourceRunes, targetRunes := []rune(source), []rune(target)
+	distance := DistanceForStrings(sourceRunes, targetRunes, DefaultOptions)
+	total := len(sourceRunes) + len(targetRunes)
 	return float32(total-distance) / float32(total)
 }
 
+// SimilarityForStringsAtLeast returns the similarity of source and target as
+// SimilarityForStrings does, and whether it is at least minSimilarity. The
+// distance is given up once the similarity can't reach minSimilarity anymore,
+// 0 and false are returned then.
+func SimilarityForStringsAtLeast(source, target string, minSimilarity float32) (float32, bool) {
+	sourceRunes, targetRunes := []rune(source), []rune(target)
+	total := len(sourceRunes) + len(targetRunes)
+
+	// one more than the float bound, the exact comparison is done below
+	maxDistance := math.MaxInt
+	if minSimilarity > 0 {
+		maxDistance = int(float32(total)*(1-minSimilarity)) + 1
+	}
+
+	distance := BoundedDistanceForStrings(sourceRunes, targetRunes, DefaultOptions, maxDistance)
+	if distance > maxDistance {
+		return 0, false
+	}
+
+	similarity := float32(total-distance) / float32(total)
+	return similarity, similarity >= minSimilarity
+}
+
 // DistanceForStrings returns the edit distance between source and target.
 func DistanceForStrings(source []rune, target []rune, op Options) int {
-	return DistanceForMatrix(MatrixForStrings(source, target, op))
+	return BoundedDistanceForStrings(source, target, op, math.MaxInt)
+}
+
+// BoundedDistanceForStrings returns the edit distance between source and
+// target if it is at most maxDistance, and a value above maxDistance
+// otherwise. Only two rows of the Levenshtein table are kept, use
+// MatrixForStrings for the whole table. With insertions and deletions costing
+// at least 1, only the band of cells around the diagonal that can lead to a
+// distance within maxDistance is filled, and the computation stops once a row
+// has no cell within it. The costs of op must not be negative.
+func BoundedDistanceForStrings(source []rune, target []rune, op Options, maxDistance int) int {
+	width := len(target) + 1
+	difference := len(source) - len(target)
+
+	// a path through cell (i, j) takes at least |i-j| insertions or deletions
+	// to get there and |difference-(i-j)| more to the end, each costing 1 or
+	// more, so only the cells with i-j in [lowest, highest] are filled.
+	banded := op.InsCost >= 1 && op.DelCost >= 1 && maxDistance < math.MaxInt
+	lowest, highest := -len(target), len(source)
+	var outside int
+	if banded {
+		if difference > maxDistance || -difference > maxDistance {
+			return maxDistance + 1
+		}
+		lowest = -((maxDistance - difference) / 2)
+		highest = (maxDistance + difference) / 2
+		outside = maxDistance + 1
+	}
+
+	// previous and current are rows i-1 and i of the table MatrixForStrings
+	// fills, the cells only depend on these two.
+	previous := make([]int, width)
+	current := make([]int, width)
+	for j := range previous {
+		if j <= -lowest {
+			previous[j] = j * op.InsCost
+		} else {
+			previous[j] = outside
+		}
+	}
+
+	for i := 1; i < len(source)+1; i++ {
+		first, last := max(0, i-highest), min(width-1, i-lowest)
+		if first > 0 {
+			current[first-1] = outside
+		}
+		if last+1 < width {
+			current[last+1] = outside
+		}
+
+		rowMin := math.MaxInt
+		if first == 0 {
+			current[0] = i * op.DelCost
+			rowMin = current[0]
+			first = 1
+		}
+		for j := first; j <= last; j++ {
+			delCost := previous[j] + op.DelCost
+			matchSubCost := previous[j-1]
+			if !op.Matches(source[i-1], target[j-1]) {
+				matchSubCost += op.SubCost
+			}
+			insCost := current[j-1] + op.InsCost
+			current[j] = min(delCost, min(matchSubCost, insCost))
+			rowMin = min(rowMin, current[j])
+		}
+
+		// the cells of the next rows can't get below the smallest one of this row
+		if rowMin > maxDistance {
+			return rowMin
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[width-1]
 }
 
 // DistanceForMatrix reads the edit distance off the given Levenshtein matrix.
@@ -74,13 +179,14 @@ func MatrixForStrings(source []rune, target []rune, op Options) [][]int {
 	matrix := make([][]int, height)
 
 	// Initialize trivial distances (from/to empty string). That is, fill
-	// the left column and the top row with row/column indices.
+	// the left column with the costs of deleting and the top row with the
+	// costs of inserting that many runes.
 	for i := 0; i < height; i++ {
 		matrix[i] = make([]int, width)
-		matrix[i][0] = i
+		matrix[i][0] = i * op.DelCost
 	}
 	for j := 1; j < width; j++ {
-		matrix[0][j] = j
+		matrix[0][j] = j * op.InsCost
 	}
 
 	// Fill in the remaining cells: for each prefix pair, choose the
@@ -100,9 +206,159 @@ func MatrixForStrings(source []rune, target []rune, op Options) [][]int {
 	return matrix
 }
 
+// EditScriptForStrings returns the cheapest edit script turning source into
+// target, backtraced through the table of MatrixForStrings. Matches are
+// preferred over the other operations of the same cost.
+func EditScriptForStrings(source []rune, target []rune, op Options) EditScript {
+	return EditScriptForMatrix(MatrixForStrings(source, target, op), source, target, op)
+}
+
+// EditScriptForMatrix backtraces the edit script turning source into target
+// through their Levenshtein matrix, made by MatrixForStrings with the same op.
+func EditScriptForMatrix(matrix [][]int, source []rune, target []rune, op Options) EditScript {
+	var script EditScript
+	i, j := len(source), len(target)
+	for i > 0 || j > 0 {
+		switch {
+		case i == 0:
+			script = append(script, Ins)
+			j--
+		case j == 0:
+			script = append(script, Del)
+			i--
+		case op.Matches(source[i-1], target[j-1]) && matrix[i-1][j-1] == matrix[i][j]:
+			script = append(script, Match)
+			i--
+			j--
+		case !op.Matches(source[i-1], target[j-1]) && matrix[i-1][j-1]+op.SubCost == matrix[i][j]:
+			script = append(script, Sub)
+			i--
+			j--
+		case matrix[i-1][j]+op.DelCost == matrix[i][j]:
+			script = append(script, Del)
+			i--
+		default:
+			script = append(script, Ins)
+			j--
+		}
+	}
+
+	// the script was built from the end
+	for left, right := 0, len(script)-1; left < right; left, right = left+1, right-1 {
+		script[left], script[right] = script[right], script[left]
+	}
+
+	return script
+}
+
+// Align renders the alignment of source and target along the script in three
+// lines: source with a gap for each insertion, a marker for each operation,
+// '|' for a match, '*' for a substitution, '-' for a deletion and '+' for an
+// insertion, and target with a gap for each deletion.
+func (script EditScript) Align(source []rune, target []rune) string {
+	var top, middle, bottom strings.Builder
+	i, j := 0, 0
+	for _, operation := range script {
+		switch operation {
+		case Match, Sub:
+			top.WriteRune(source[i])
+			bottom.WriteRune(target[j])
+			if operation == Match {
+				middle.WriteByte('|')
+			} else {
+				middle.WriteByte('*')
+			}
+			i++
+			j++
+		case Del:
+			top.WriteRune(source[i])
+			middle.WriteByte('-')
+			bottom.WriteByte(' ')
+			i++
+		case Ins:
+			top.WriteByte(' ')
+			middle.WriteByte('+')
+			bottom.WriteRune(target[j])
+			j++
+		}
+	}
+
+	return top.String() + "\n" + middle.String() + "\n" + bottom.String()
+}
+
+// DiffWords compares source and target word by word, ignoring case and the
+// punctuation around the words. It renders target the way git diff
+// --word-diff does: the words of source missing from target as [-words-] and
+// the words added by target as {+words+}.
+func DiffWords(source, target string) string {
+	sourceWords, targetWords := strings.Fields(source), strings.Fields(target)
+
+	// number the distinct words, so that the edit script over runes aligns words
+	ids := make(map[string]rune)
+	toRunes := func(words []string) []rune {
+		runes := make([]rune, len(words))
+		for i, word := range words {
+			word = strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+			id, ok := ids[word]
+			if !ok {
+				id = rune(len(ids))
+				ids[word] = id
+			}
+			runes[i] = id
+		}
+		return runes
+	}
+	sourceRunes, targetRunes := toRunes(sourceWords), toRunes(targetWords)
+
+	var parts []string
+	var removed, added []string
+	flush := func() {
+		if len(removed) > 0 {
+			parts = append(parts, "[-"+strings.Join(removed, " ")+"-]")
+			removed = nil
+		}
+		if len(added) > 0 {
+			parts = append(parts, "{+"+strings.Join(added, " ")+"+}")
+			added = nil
+		}
+	}
+
+	i, j := 0, 0
+	for _, operation := range EditScriptForStrings(sourceRunes, targetRunes, DefaultOptions) {
+		switch operation {
+		case Match:
+			flush()
+			parts = append(parts, targetWords[j])
+			i++
+			j++
+		case Sub:
+			removed = append(removed, sourceWords[i])
+			added = append(added, targetWords[j])
+			i++
+			j++
+		case Del:
+			removed = append(removed, sourceWords[i])
+			i++
+		case Ins:
+			added = append(added, targetWords[j])
+			j++
+		}
+	}
+	flush()
+
+	return strings.Join(parts, " ")
+}
+
 func min(a int, b int) int {
 	if b < a {
 		return b
 	}
 	return a
 }
+
+func max(a int, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,178 @@
+package nlp
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var (
+	benchmarkSource = []rune("What is the main purpose of the periMICA edge computing device?")
+	benchmarkTarget = []rune("what are the functions of the periMICA container platform")
+)
+
+func FuzzBoundedDistance(f *testing.F) {
+	f.Add("", "", 0)
+	f.Add("kitten", "sitting", 3)
+	f.Add("kitten", "sitting", 100)
+	f.Add("periMICA", "periNODE", 2)
+	f.Add("what is a gateway", "a gateway is what", 5)
+	f.Add("größe", "grösse", 1)
+
+	f.Fuzz(func(t *testing.T, source, target string, maxDistance int) {
+		sourceRunes, targetRunes := []rune(source), []rune(target)
+		if len(sourceRunes) > 64 || len(targetRunes) > 64 {
+			t.Skip()
+		}
+		if maxDistance < 0 {
+			maxDistance = -(maxDistance + 1)
+		}
+		maxDistance %= 200
+
+		// the full table, as the distance was computed before it was bounded.
+		want := DistanceForMatrix(MatrixForStrings(sourceRunes, targetRunes, DefaultOptions))
+
+		if got := DistanceForStrings(sourceRunes, targetRunes, DefaultOptions); got != want {
+			t.Fatalf("DistanceForStrings(%q, %q) = %d, want %d", source, target, got, want)
+		}
+
+		got := BoundedDistanceForStrings(sourceRunes, targetRunes, DefaultOptions, maxDistance)
+		if want <= maxDistance && got != want {
+			t.Fatalf("BoundedDistanceForStrings(%q, %q, %d) = %d, want %d",
+				source, target, maxDistance, got, want)
+		}
+		if want > maxDistance && got <= maxDistance {
+			t.Fatalf("BoundedDistanceForStrings(%q, %q, %d) = %d, want more than %d",
+				source, target, maxDistance, got, maxDistance)
+		}
+	})
+}
+
+// scriptCost returns the cost of script, failing if it doesn't turn source
+// into target.
+func scriptCost(t *testing.T, script EditScript, source, target []rune, op Options) int {
+	t.Helper()
+
+	cost, i, j := 0, 0, 0
+	for _, operation := range script {
+		switch operation {
+		case Match, Sub:
+			if i >= len(source) || j >= len(target) || op.Matches(source[i], target[j]) != (operation == Match) {
+				t.Fatalf("%v of %q and %q: wrong %v at %d, %d", script, string(source), string(target), operation, i, j)
+			}
+			if operation == Sub {
+				cost += op.SubCost
+			}
+			i++
+			j++
+		case Del:
+			cost += op.DelCost
+			i++
+		case Ins:
+			cost += op.InsCost
+			j++
+		}
+	}
+	if i != len(source) || j != len(target) {
+		t.Fatalf("%v of %q and %q ends at %d, %d", script, string(source), string(target), i, j)
+	}
+
+	return cost
+}
+
+func TestEditScriptCost(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomRunes := func() []rune {
+		runes := make([]rune, random.Intn(12))
+		for i := range runes {
+			runes[i] = []rune("abcä ")[random.Intn(5)]
+		}
+		return runes
+	}
+
+	options := []Options{
+		DefaultOptions,
+		{InsCost: 1, DelCost: 1, SubCost: 1, Matches: DefaultOptions.Matches},
+		{InsCost: 2, DelCost: 3, SubCost: 4, Matches: DefaultOptions.Matches},
+	}
+	for i := 0; i < 1000; i++ {
+		source, target := randomRunes(), randomRunes()
+		for _, op := range options {
+			script := EditScriptForStrings(source, target, op)
+			want := DistanceForStrings(source, target, op)
+			if cost := scriptCost(t, script, source, target, op); cost != want {
+				t.Fatalf("%v of %q and %q costs %d, the distance is %d", script, string(source), string(target), cost, want)
+			}
+			if got := DistanceForMatrix(MatrixForStrings(source, target, op)); got != want {
+				t.Fatalf("the matrix of %q and %q gives %d, the distance is %d", string(source), string(target), got, want)
+			}
+			if got := BoundedDistanceForStrings(source, target, op, want); got != want {
+				t.Fatalf("BoundedDistanceForStrings(%q, %q, %d) = %d", string(source), string(target), want, got)
+			}
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		source, target string
+		want           string
+	}{
+		{"kitten", "sitting", "kitten \n*|||*|+\nsitting"},
+		{"flaw", "lawn", "flaw \n-|||+\n lawn"},
+		{"größe", "grösse", "grö ße\n|||+*|\ngrösse"},
+		{"abc", "", "abc\n---\n   "},
+		{"", "ab", "  \n++\nab"},
+		{"", "", "\n\n"},
+	}
+	for _, test := range tests {
+		source, target := []rune(test.source), []rune(test.target)
+		if got := EditScriptForStrings(source, target, DefaultOptions).Align(source, target); got != test.want {
+			t.Errorf("Align(%q, %q) =\n%s\nwant\n%s", test.source, test.target, got, test.want)
+		}
+	}
+}
+
+func TestDiffWords(t *testing.T) {
+	tests := []struct {
+		source, target string
+		want           string
+	}{
+		{"What is the periMICA?", "what is the periMICA", "what is the periMICA"},
+		{"What is the periMICA?", "what is the periNODE?", "what is the [-periMICA?-] {+periNODE?+}"},
+		{"How do I reset the gateway", "How can I reset the gateway quickly", "How [-do-] {+can+} I reset the gateway {+quickly+}"},
+		{"the gateway forwards data", "gateway forwards the data", "[-the-] gateway forwards {+the+} data"},
+		{"", "new words", "{+new words+}"},
+		{"old words", "", "[-old words-]"},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := DiffWords(test.source, test.target); got != test.want {
+			t.Errorf("DiffWords(%q, %q) = %q, want %q", test.source, test.target, got, test.want)
+		}
+	}
+}
+
+func BenchmarkDistanceMatrix(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DistanceForMatrix(MatrixForStrings(benchmarkSource, benchmarkTarget, DefaultOptions))
+	}
+}
+
+func BenchmarkDistance(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		DistanceForStrings(benchmarkSource, benchmarkTarget, DefaultOptions)
+	}
+}
+
+func BenchmarkBoundedDistance(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		BoundedDistanceForStrings(benchmarkSource, benchmarkTarget, DefaultOptions, 20)
+	}
+}
+
+func BenchmarkSimilarityAtLeast(b *testing.B) {
+	source, target := string(benchmarkSource), string(benchmarkTarget)
+	for i := 0; i < b.N; i++ {
+		SimilarityForStringsAtLeast(source, target, 0.8)
+	}
+}
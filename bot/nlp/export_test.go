@@ -0,0 +1,5 @@
+package nlp
+
+// WriteTestDictionaries writes the test dictionaries for the tests of
+// nlp_test, which load them through the storages.
+var WriteTestDictionaries = writeTestDictionaries
@@ -0,0 +1,106 @@
+package nlp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wangbin/jiebago"
+	"github.com/wangbin/jiebago/dictionary"
+)
+
+type (
+	// TFIDFExtracter extracts the keywords of texts by TF-IDF, giving the same
+	// keywords as the TagExtracter of jiebago. Unlike that one, it shares
+	// the segmenter and its dictionary with the other users of Segmenter.
+	TFIDFExtracter struct {
+		segmenter *jiebago.Segmenter
+		idf       *idfTable
+		stopWords map[string]bool
+	}
+
+	// idfTable holds the inverse document frequencies of the words, the words
+	// missing from it get the median one.
+	idfTable struct {
+		frequencies map[string]float64
+		median      float64
+	}
+
+	weightedWord struct {
+		word   string
+		weight float64
+	}
+)
+
+// ExtractKeywords returns the topK words of sentence with the highest TF-IDF,
+// leaving out the words of a single rune and the stop words.
+func (extracter *TFIDFExtracter) ExtractKeywords(sentence string, topK int) []string {
+	counts := make(map[string]float64)
+	for word := range extracter.segmenter.Cut(sentence, true) {
+		word = strings.TrimSpace(word)
+		if utf8.RuneCountInString(word) < 2 || extracter.stopWords[word] {
+			continue
+		}
+		counts[word]++
+	}
+
+	var total float64
+	for _, count := range counts {
+		total += count
+	}
+
+	words := make([]weightedWord, 0, len(counts))
+	for word, count := range counts {
+		idf, ok := extracter.idf.frequencies[word]
+		if !ok {
+			idf = extracter.idf.median
+		}
+		words = append(words, weightedWord{
+			word:   word,
+			weight: idf * (count / total),
+		})
+	}
+	// the order of jiebago: by weight, then by word, both descending.
+	sort.Slice(words, func(i, j int) bool {
+		if words[i].weight != words[j].weight {
+			return words[i].weight > words[j].weight
+		}
+		return words[i].word > words[j].word
+	})
+
+	if len(words) > topK {
+		words = words[:topK]
+	}
+	keywords := make([]string, len(words))
+	for i := range words {
+		keywords[i] = words[i].word
+	}
+
+	return keywords
+}
+
+func newIdfTable() *idfTable {
+	return &idfTable{
+		frequencies: make(map[string]float64),
+	}
+}
+
+// AddToken adds a word with its idf, the idf tables are not changed after
+// loading, so it only serves dictionary.DictLoader.
+func (table *idfTable) AddToken(token dictionary.Token) {
+	table.frequencies[token.Text()] = token.Frequency()
+}
+
+// Load reads the words with their idfs and takes the median of the idfs.
+func (table *idfTable) Load(tokens <-chan dictionary.Token) {
+	var frequencies []float64
+	for token := range tokens {
+		table.frequencies[token.Text()] = token.Frequency()
+		frequencies = append(frequencies, token.Frequency())
+	}
+
+	if len(frequencies) > 0 {
+		sort.Float64s(frequencies)
+		table.median = frequencies[len(frequencies)/2]
+	}
+}
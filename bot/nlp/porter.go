@@ -0,0 +1,347 @@
+package nlp
+
+// Stem reduces an English word to its stem with the Porter stemming
+// algorithm, so that "connected", "connecting" and "connection" all become
+// "connect". The word is expected in lower case, words that are not plain
+// ASCII letters or are shorter than 3 letters are returned as they are.
+func Stem(word string) string {
+	if len(word) < 3 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	stemmer := porterStemmer{b: []byte(word), k: len(word) - 1}
+	stemmer.step1ab()
+	if stemmer.k > 0 {
+		stemmer.step1c()
+		stemmer.step2()
+		stemmer.step3()
+		stemmer.step4()
+		stemmer.step5()
+	}
+
+	return string(stemmer.b[:stemmer.k+1])
+}
+
+// porterStemmer follows the reference implementation of the algorithm:
+// b[0:k+1] is the word being stemmed and j marks the end of the stem when
+// an ending has been matched.
+type porterStemmer struct {
+	b    []byte
+	k, j int
+}
+
+// cons tells whether b[i] is a consonant.
+func (s *porterStemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		if i == 0 {
+			return true
+		}
+		return !s.cons(i - 1)
+	default:
+		return true
+	}
+}
+
+// m measures the number of consonant sequences in b[0:j+1]. With c a
+// consonant sequence and v a vowel sequence, <c><v> gives 0, <c>vc<v> gives 1,
+// <c>vcvc<v> gives 2 and so on.
+func (s *porterStemmer) m() int {
+	n := 0
+	i := 0
+	for {
+		if i > s.j {
+			return n
+		}
+		if !s.cons(i) {
+			break
+		}
+		i++
+	}
+	i++
+	for {
+		for {
+			if i > s.j {
+				return n
+			}
+			if s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+		n++
+		for {
+			if i > s.j {
+				return n
+			}
+			if !s.cons(i) {
+				break
+			}
+			i++
+		}
+		i++
+	}
+}
+
+// vowelInStem tells whether b[0:j+1] contains a vowel.
+func (s *porterStemmer) vowelInStem() bool {
+	for i := 0; i <= s.j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleC tells whether b[j-1:j+1] is a double consonant.
+func (s *porterStemmer) doubleC(j int) bool {
+	if j < 1 || s.b[j] != s.b[j-1] {
+		return false
+	}
+	return s.cons(j)
+}
+
+// cvc tells whether b[i-2:i+1] is consonant-vowel-consonant and the last
+// consonant is not w, x or y. It restores an e at the end of short words,
+// like cav(e), lov(e), hop(e), but not snow, box or tray.
+func (s *porterStemmer) cvc(i int) bool {
+	if i < 2 || !s.cons(i) || s.cons(i-1) || !s.cons(i-2) {
+		return false
+	}
+	switch s.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends tells whether b[0:k+1] ends with suffix and sets j to the end of the
+// stem before it.
+func (s *porterStemmer) ends(suffix string) bool {
+	length := len(suffix)
+	if length > s.k+1 {
+		return false
+	}
+	if string(s.b[s.k-length+1:s.k+1]) != suffix {
+		return false
+	}
+	s.j = s.k - length
+	return true
+}
+
+// setTo replaces b[j+1:k+1] with replacement.
+func (s *porterStemmer) setTo(replacement string) {
+	s.b = append(s.b[:s.j+1], replacement...)
+	s.k = s.j + len(replacement)
+}
+
+// r replaces the ending with replacement if the stem has a measure above 0.
+func (s *porterStemmer) r(replacement string) {
+	if s.m() > 0 {
+		s.setTo(replacement)
+	}
+}
+
+// step1ab gets rid of plurals and -ed or -ing.
+func (s *porterStemmer) step1ab() {
+	if s.b[s.k] == 's' {
+		if s.ends("sses") {
+			s.k -= 2
+		} else if s.ends("ies") {
+			s.setTo("i")
+		} else if s.b[s.k-1] != 's' {
+			s.k--
+		}
+	}
+
+	if s.ends("eed") {
+		if s.m() > 0 {
+			s.k--
+		}
+	} else if (s.ends("ed") || s.ends("ing")) && s.vowelInStem() {
+		s.k = s.j
+		if s.ends("at") {
+			s.setTo("ate")
+		} else if s.ends("bl") {
+			s.setTo("ble")
+		} else if s.ends("iz") {
+			s.setTo("ize")
+		} else if s.doubleC(s.k) {
+			s.k--
+			switch s.b[s.k] {
+			case 'l', 's', 'z':
+				s.k++
+			}
+		} else if s.j = s.k; s.m() == 1 && s.cvc(s.k) {
+			s.setTo("e")
+		}
+	}
+}
+
+// step1c turns a terminal y into i when there is another vowel in the stem.
+func (s *porterStemmer) step1c() {
+	if s.ends("y") && s.vowelInStem() {
+		s.b[s.k] = 'i'
+	}
+}
+
+// step2 maps double suffixes to single ones, -ization to -ize and so on.
+func (s *porterStemmer) step2() {
+	if s.k < 1 {
+		return
+	}
+
+	switch s.b[s.k-1] {
+	case 'a':
+		if s.ends("ational") {
+			s.r("ate")
+		} else if s.ends("tional") {
+			s.r("tion")
+		}
+	case 'c':
+		if s.ends("enci") {
+			s.r("ence")
+		} else if s.ends("anci") {
+			s.r("ance")
+		}
+	case 'e':
+		if s.ends("izer") {
+			s.r("ize")
+		}
+	case 'l':
+		if s.ends("bli") {
+			s.r("ble")
+		} else if s.ends("alli") {
+			s.r("al")
+		} else if s.ends("entli") {
+			s.r("ent")
+		} else if s.ends("eli") {
+			s.r("e")
+		} else if s.ends("ousli") {
+			s.r("ous")
+		}
+	case 'o':
+		if s.ends("ization") {
+			s.r("ize")
+		} else if s.ends("ation") {
+			s.r("ate")
+		} else if s.ends("ator") {
+			s.r("ate")
+		}
+	case 's':
+		if s.ends("alism") {
+			s.r("al")
+		} else if s.ends("iveness") {
+			s.r("ive")
+		} else if s.ends("fulness") {
+			s.r("ful")
+		} else if s.ends("ousness") {
+			s.r("ous")
+		}
+	case 't':
+		if s.ends("aliti") {
+			s.r("al")
+		} else if s.ends("iviti") {
+			s.r("ive")
+		} else if s.ends("biliti") {
+			s.r("ble")
+		}
+	case 'g':
+		if s.ends("logi") {
+			s.r("log")
+		}
+	}
+}
+
+// step3 deals with -ic-, -full, -ness and the like.
+func (s *porterStemmer) step3() {
+	switch s.b[s.k] {
+	case 'e':
+		if s.ends("icate") {
+			s.r("ic")
+		} else if s.ends("ative") {
+			s.r("")
+		} else if s.ends("alize") {
+			s.r("al")
+		}
+	case 'i':
+		if s.ends("iciti") {
+			s.r("ic")
+		}
+	case 'l':
+		if s.ends("ical") {
+			s.r("ic")
+		} else if s.ends("ful") {
+			s.r("")
+		}
+	case 's':
+		if s.ends("ness") {
+			s.r("")
+		}
+	}
+}
+
+// step4 takes off -ant, -ence and the like when the measure is above 1.
+func (s *porterStemmer) step4() {
+	if s.k < 1 {
+		return
+	}
+
+	matched := false
+	switch s.b[s.k-1] {
+	case 'a':
+		matched = s.ends("al")
+	case 'c':
+		matched = s.ends("ance") || s.ends("ence")
+	case 'e':
+		matched = s.ends("er")
+	case 'i':
+		matched = s.ends("ic")
+	case 'l':
+		matched = s.ends("able") || s.ends("ible")
+	case 'n':
+		matched = s.ends("ant") || s.ends("ement") || s.ends("ment") || s.ends("ent")
+	case 'o':
+		if s.ends("ion") {
+			matched = s.j >= 0 && (s.b[s.j] == 's' || s.b[s.j] == 't')
+		} else {
+			matched = s.ends("ou")
+		}
+	case 's':
+		matched = s.ends("ism")
+	case 't':
+		matched = s.ends("ate") || s.ends("iti")
+	case 'u':
+		matched = s.ends("ous")
+	case 'v':
+		matched = s.ends("ive")
+	case 'z':
+		matched = s.ends("ize")
+	}
+
+	if matched && s.m() > 1 {
+		s.k = s.j
+	}
+}
+
+// step5 removes a final -e and turns -ll into -l when the measure is above 1.
+func (s *porterStemmer) step5() {
+	s.j = s.k
+	if s.b[s.k] == 'e' {
+		m := s.m()
+		if m > 1 || (m == 1 && !s.cvc(s.k-1)) {
+			s.k--
+		}
+	}
+	if s.b[s.k] == 'l' && s.doubleC(s.k) && s.m() > 1 {
+		s.k--
+	}
+}
@@ -0,0 +1,110 @@
+package nlp
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/wangbin/jiebago"
+	"github.com/wangbin/jiebago/analyse"
+	"github.com/wangbin/jiebago/dictionary"
+)
+
+// sharedResource is loaded by the first caller asking for it, the others
+// wait for it and get the same one.
+type sharedResource struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+var (
+	resourcesLock sync.Mutex
+	resources     = make(map[string]*sharedResource)
+)
+
+// Segmenter returns the jiebago segmenter of dictFile. It is loaded once per
+// process and shared by all storages, which only read it.
+func Segmenter(dictFile string) (*jiebago.Segmenter, error) {
+	value, err := loadShared("segmenter", []string{dictFile}, func() (any, error) {
+		var segmenter jiebago.Segmenter
+		if err := segmenter.LoadDictionary(dictFile); err != nil {
+			return nil, err
+		}
+
+		return &segmenter, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*jiebago.Segmenter), nil
+}
+
+// KeywordExtracter returns the keyword extracter of the files, loaded once
+// per process and shared like Segmenter. It cuts the texts with the shared
+// segmenter of dictFile, where the TagExtracter of jiebago would parse the
+// dictionary a second time. An empty stopWordsFile keeps the stop words of
+// jiebago.
+func KeywordExtracter(dictFile, idfFile, stopWordsFile string) (*TFIDFExtracter, error) {
+	value, err := loadShared("extracter", []string{dictFile, idfFile, stopWordsFile}, func() (any, error) {
+		segmenter, err := Segmenter(dictFile)
+		if err != nil {
+			return nil, err
+		}
+
+		extracter := &TFIDFExtracter{
+			segmenter: segmenter,
+			idf:       newIdfTable(),
+			stopWords: make(map[string]bool, len(analyse.DefaultStopWordMap)),
+		}
+		if err := dictionary.LoadDictionary(extracter.idf, idfFile); err != nil {
+			return nil, err
+		}
+		for word := range analyse.DefaultStopWordMap {
+			extracter.stopWords[word] = true
+		}
+		if len(stopWordsFile) > 0 {
+			words, err := ReadWords(stopWordsFile)
+			if err != nil {
+				return nil, err
+			}
+			for _, word := range words {
+				extracter.stopWords[word] = true
+			}
+		}
+
+		return extracter, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*TFIDFExtracter), nil
+}
+
+// loadShared returns the resource of kind loaded from files, calling load
+// if it is not loaded yet. Failed loads are forgotten, so they are retried.
+func loadShared(kind string, files []string, load func() (any, error)) (any, error) {
+	key := kind + "\x00" + strings.Join(files, "\x00")
+
+	resourcesLock.Lock()
+	resource, ok := resources[key]
+	if !ok {
+		resource = new(sharedResource)
+		resources[key] = resource
+	}
+	resourcesLock.Unlock()
+
+	resource.once.Do(func() {
+		resource.value, resource.err = load()
+	})
+	if resource.err != nil {
+		resourcesLock.Lock()
+		if resources[key] == resource {
+			delete(resources, key)
+		}
+		resourcesLock.Unlock()
+	}
+
+	return resource.value, resource.err
+}
@@ -0,0 +1,100 @@
+package nlp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wangbin/jiebago/analyse"
+)
+
+var testSentences = []string{
+	"我来到北京清华大学",
+	"网关把传感器数据转发到云平台",
+	"the gateway forwards the sensor data of the sensor to the cloud",
+	"periMICA 网关 periMICA 容器 数据 数据 数据",
+}
+
+// writeTestDictionaries writes a small jieba dictionary and idf file to dir.
+func writeTestDictionaries(tb testing.TB, dir string) (dictFile, idfFile string) {
+	tb.Helper()
+
+	words := []string{
+		"我 100 r", "来到 50 v", "北京 80 ns", "清华 30 nz", "清华大学 20 nt", "大学 40 n",
+		"网关 10 n", "传感器 10 n", "数据 30 n", "转发 8 v", "到 90 p", "云 20 n",
+		"平台 25 n", "云平台 5 n", "容器 6 n", "把 60 p",
+	}
+	for i := 0; i < 2000; i++ {
+		words = append(words, fmt.Sprintf("词%d 3 n", i))
+	}
+	idfs := []string{
+		"北京 6.2", "清华大学 11.5", "大学 5.1", "网关 9.2", "传感器 8.7", "数据 4.3",
+		"转发 7.9", "云平台 10.1", "容器 8.8", "gateway 9.9", "sensor 8.1", "cloud 7.7",
+	}
+
+	dictFile = filepath.Join(dir, "dict.txt")
+	idfFile = filepath.Join(dir, "idf.txt")
+	if err := os.WriteFile(dictFile, []byte(strings.Join(words, "\n")+"\n"), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	if err := os.WriteFile(idfFile, []byte(strings.Join(idfs, "\n")+"\n"), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+
+	return dictFile, idfFile
+}
+
+func TestKeywordExtracterSharesSegmenter(t *testing.T) {
+	dictFile, idfFile := writeTestDictionaries(t, t.TempDir())
+
+	segmenter, err := Segmenter(dictFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extracter, err := KeywordExtracter(dictFile, idfFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extracter.segmenter != segmenter {
+		t.Fatal("the extracter loaded its own segmenter")
+	}
+
+	again, err := KeywordExtracter(dictFile, idfFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != extracter {
+		t.Fatal("the extracter was loaded twice")
+	}
+}
+
+func TestKeywordExtracterMatchesJiebago(t *testing.T) {
+	dictFile, idfFile := writeTestDictionaries(t, t.TempDir())
+
+	extracter, err := KeywordExtracter(dictFile, idfFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var jiebago analyse.TagExtracter
+	if err := jiebago.LoadDictionary(dictFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := jiebago.LoadIdf(idfFile); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sentence := range testSentences {
+		for _, topK := range []int{1, 3, 5} {
+			want := []string{}
+			for _, tag := range jiebago.ExtractTags(sentence, topK) {
+				want = append(want, tag.Text())
+			}
+			if got := extracter.ExtractKeywords(sentence, topK); !reflect.DeepEqual(got, want) {
+				t.Errorf("ExtractKeywords(%q, %d) = %q, jiebago gives %q", sentence, topK, got, want)
+			}
+		}
+	}
+}
@@ -7,34 +7,196 @@ import (
 	"github.com/zeromicro/go-zero/core/lang"
 )
 
+const (
+	// LangAuto picks the detector by the characters of the sentence,
+	// English for pure ASCII sentences, German otherwise.
+	LangAuto    = ""
+	LangEnglish = "en"
+	LangGerman  = "de"
+)
+
+type QuestionDetector func(sentence string) bool
+
 var (
-	embededQuestionMarks = []string{
+	embededQuestionMarks = createWordSet([]string{
 		"was", "warum", "wieso", "weshalb", "wie", "wo", "wann",
-	}
+	})
 
 	endQuestionChars = createSet([]rune{
 		'?',
 		'？',
 	})
+
+	// sentences ending with an exclamation mark are no questions, even if
+	// they start like one: "what a nice day!", "wie schön!".
+	endExclamationChars = createSet([]rune{
+		'!',
+		'！',
+	})
+
+	englishQuestionWords = createWordSet([]string{
+		"what", "what's", "whats", "why", "when", "when's", "where", "where's",
+		"wheres", "who", "who's", "whom", "whose", "which", "how", "how's", "hows",
+	})
+
+	englishAuxiliaries = createWordSet([]string{
+		"am", "is", "isn't", "are", "aren't", "was", "wasn't", "were", "weren't",
+		"do", "don't", "does", "doesn't", "did", "didn't",
+		"have", "haven't", "has", "hasn't", "had", "hadn't",
+		"can", "can't", "cannot", "could", "couldn't", "will", "won't",
+		"would", "wouldn't", "shall", "should", "shouldn't",
+		"may", "might", "must",
+	})
+
+	// do and have also start imperatives and set phrases, like "do it now" or
+	// "have a nice day", so they only count if no such word follows.
+	englishImperativeAuxiliaries = createWordSet([]string{"do", "have"})
+	englishNonSubjects           = createWordSet([]string{
+		"a", "an", "it", "me", "not", "so", "that", "this",
+	})
+
+	// "what a" and "what an" start exclamations, like "what a nice day".
+	englishExclamativeArticles = createWordSet([]string{"a", "an"})
+
+	// "how" followed by an adjective starts an exclamation, like "how nice of
+	// you", unless an auxiliary follows it, like "how fast is it". These words
+	// after "how" ask questions, like "how many" and "how the gateway works".
+	englishHowQuestions = createWordSet([]string{
+		"a", "about", "an", "big", "come", "exactly", "far", "fast", "he", "high",
+		"i", "its", "it", "large", "long", "many", "much", "my", "often", "old",
+		"our", "she", "small", "soon", "that", "the", "their", "these", "they",
+		"this", "those", "to", "we", "you", "your",
+	})
+
+	// subordinators starting a clause before the main one, like "when the
+	// sensor is connected, the LED turns green".
+	englishSubordinators = createWordSet([]string{
+		"after", "although", "because", "before", "if", "once", "unless", "until",
+		"when", "whenever", "where", "wherever", "while",
+	})
+
+	// leading words that don't change whether a sentence is a question.
+	englishFillers = createWordSet([]string{
+		"and", "but", "hey", "hi", "hello", "now", "ok", "okay", "so", "then", "well",
+	})
+
+	questionDetectors = map[string]QuestionDetector{
+		LangEnglish: isEnglishQuestion,
+		LangGerman:  isGermanQuestion,
+	}
 )
 
+// IsQuestion tells whether sentence is a question, picking the language by
+// the characters of the sentence.
 func IsQuestion(sentence string) bool {
-	// we don't check whether question or not in English
+	return IsQuestionIn(LangAuto, sentence)
+}
+
+// IsQuestionIn tells whether sentence is a question in the given language.
+// Unknown languages fall back to LangAuto.
+func IsQuestionIn(language, sentence string) bool {
+	if detector, ok := questionDetectors[strings.ToLower(language)]; ok {
+		return detector(sentence)
+	}
+
 	if isAscii(sentence) {
+		return isEnglishQuestion(sentence)
+	}
+
+	return isGermanQuestion(sentence)
+}
+
+func isEnglishQuestion(sentence string) bool {
+	if endsWithQuestionMark(sentence) {
+		return true
+	}
+	if endsWithExclamationMark(sentence) {
+		return false
+	}
+
+	if main, ok := englishMainClause(sentence); ok {
+		return isEnglishQuestion(main)
+	}
+
+	words := dropEnglishFillers(englishWords(sentence))
+	if len(words) == 0 {
 		return false
 	}
 
-	chars := []rune(strings.TrimSpace(sentence))
-	if len(chars) == 0 {
+	if englishQuestionWords[words[0]] {
+		return !isEnglishExclamation(words)
+	}
+
+	if !englishAuxiliaries[words[0]] || len(words) < 2 {
 		return false
 	}
 
-	if _, ok := endQuestionChars[chars[len(chars)-1]]; ok {
+	if englishImperativeAuxiliaries[words[0]] {
+		return !englishNonSubjects[words[1]]
+	}
+
+	return words[1] != "not"
+}
+
+// englishMainClause returns the part of sentence after a leading subordinate
+// clause ending with a comma, which decides whether sentence is a question,
+// false if sentence doesn't start with one. "when is it, ..." asks instead.
+func englishMainClause(sentence string) (string, bool) {
+	clauses := strings.Split(sentence, ",")
+	for i, clause := range clauses[:len(clauses)-1] {
+		words := dropEnglishFillers(englishWords(clause))
+		if len(words) == 0 {
+			continue
+		}
+
+		subordinate := len(words) > 1 && englishSubordinators[words[0]] && !englishAuxiliaries[words[1]]
+		return strings.Join(clauses[i+1:], ","), subordinate
+	}
+
+	return "", false
+}
+
+// dropEnglishFillers drops the leading fillers of words.
+func dropEnglishFillers(words []string) []string {
+	for len(words) > 0 && englishFillers[words[0]] {
+		words = words[1:]
+	}
+
+	return words
+}
+
+// isEnglishExclamation tells whether words, starting with a question word,
+// are an exclamation instead.
+func isEnglishExclamation(words []string) bool {
+	if len(words) < 2 {
+		return false
+	}
+
+	switch words[0] {
+	case "what":
+		return englishExclamativeArticles[words[1]]
+	case "how":
+		if englishAuxiliaries[words[1]] || englishHowQuestions[words[1]] {
+			return false
+		}
+		return len(words) < 3 || !englishAuxiliaries[words[2]]
+	default:
+		return false
+	}
+}
+
+func isGermanQuestion(sentence string) bool {
+	if endsWithQuestionMark(sentence) {
 		return true
 	}
+	if endsWithExclamationMark(sentence) {
+		return false
+	}
 
-	for i := range embededQuestionMarks {
-		if strings.Contains(sentence, embededQuestionMarks[i]) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(sentence), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if embededQuestionMarks[word] {
 			return true
 		}
 	}
@@ -42,6 +204,33 @@ func IsQuestion(sentence string) bool {
 	return false
 }
 
+func endsWithQuestionMark(sentence string) bool {
+	return endsWith(sentence, endQuestionChars)
+}
+
+func endsWithExclamationMark(sentence string) bool {
+	return endsWith(sentence, endExclamationChars)
+}
+
+func endsWith(sentence string, chars map[rune]lang.PlaceholderType) bool {
+	trimmed := []rune(strings.TrimSpace(sentence))
+	if len(trimmed) == 0 {
+		return false
+	}
+
+	_, ok := chars[trimmed[len(trimmed)-1]]
+	return ok
+}
+
+// englishWords lowercases sentence and splits it into words, keeping
+// apostrophes so that contractions like "isn't" stay intact.
+func englishWords(sentence string) []string {
+	sentence = strings.ReplaceAll(strings.ToLower(sentence), "’", "'")
+	return strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
 func createSet(items []rune) map[rune]lang.PlaceholderType {
 	ret := make(map[rune]lang.PlaceholderType)
 	for _, item := range items {
@@ -50,6 +239,14 @@ func createSet(items []rune) map[rune]lang.PlaceholderType {
 	return ret
 }
 
+func createWordSet(items []string) map[string]bool {
+	ret := make(map[string]bool, len(items))
+	for _, item := range items {
+		ret[item] = true
+	}
+	return ret
+}
+
 func isAscii(s string) bool {
 	for i := 0; i < len(s); i++ {
 		if s[i] > unicode.MaxASCII {
@@ -0,0 +1,82 @@
+package nlp
+
+import "testing"
+
+func TestIsQuestionIn(t *testing.T) {
+	tests := map[string][]struct {
+		sentence string
+		question bool
+	}{
+		LangEnglish: {
+			{"What is the periMICA?", true},
+			{"what is the periMICA", true},
+			{"where's the manual", true},
+			{"how do I reset the gateway", true},
+			{"how many sensors can I connect", true},
+			{"how fast is the data forwarded", true},
+			{"how it works", true},
+			{"how to configure the firewall", true},
+			{"is the sensor waterproof", true},
+			{"isn't it supported", true},
+			{"can't you connect it", true},
+			{"does periNODE support SPE", true},
+			{"do you ship to Germany", true},
+			{"well, which app should I use", true},
+			{"hi, can you help me", true},
+			{"The periMICA is an edge device.", false},
+			{"the gateway forwards data", false},
+			{"do it now", false},
+			{"have a nice day", false},
+			{"is not a question", false},
+			{"ok", false},
+			{"", false},
+			// exclamations starting like questions
+			{"what a nice day", false},
+			{"What an idea!", false},
+			{"how nice of you", false},
+			{"how beautiful it is", false},
+			{"How cool", false},
+			{"what is this!", false},
+			// a subordinate clause before the main one
+			{"When the sensor is connected, the LED turns green.", false},
+			{"when the sensor is connected, the LED turns green", false},
+			{"If the LED is red, the sensor is broken", false},
+			{"Well, while it updates, the gateway keeps forwarding data", false},
+			{"When the sensor is connected, does the LED turn green", true},
+			{"if the LED is red, what should I do", true},
+			{"when is the delivery, tomorrow", true},
+			{"where are the manuals, online", true},
+			{"when the gateway restarts", true},
+		},
+		LangGerman: {
+			{"Was ist das periMICA?", true},
+			{"was ist das periMICA", true},
+			{"Wie funktioniert der Sensor", true},
+			{"ich weiß nicht, wo das Handbuch ist", true},
+			{"Wann wird geliefert", true},
+			{"Ist der Sensor wasserdicht?", true},
+			{"Das periMICA ist ein Edge-Gerät.", false},
+			{"Das war etwas schnell", false},
+			{"Wort für Wort", false},
+			{"", false},
+			// exclamations starting like questions
+			{"Was für ein schöner Tag!", false},
+			{"Wie schön!", false},
+		},
+		LangAuto: {
+			{"what is the periMICA", true},
+			{"what a nice day", false},
+			{"Wie groß ist der Schaltschrank", true},
+			{"Schöne Grüße aus München", false},
+			{"你好？", true},
+		},
+	}
+
+	for language, cases := range tests {
+		for _, test := range cases {
+			if got := IsQuestionIn(language, test.sentence); got != test.question {
+				t.Errorf("IsQuestionIn(%q, %q) = %t, want %t", language, test.sentence, got, test.question)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package nlp_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golangChatBot/bot/adapters/storage"
+	"golangChatBot/bot/nlp"
+)
+
+const bundledModel = "../../cli/chat/PMFuncOverView.gob"
+
+// BenchmarkStartup loads the bundled model with the jieba dictionaries, the
+// way the front ends start. With the shared registry the dictionaries are
+// parsed by the first load only, without it by every load, which is what
+// every storage did before they were shared.
+func BenchmarkStartup(b *testing.B) {
+	dir := b.TempDir()
+	dictFile, idfFile := nlp.WriteTestDictionaries(b, dir)
+
+	load := func(b *testing.B, dictFile, idfFile string) {
+		config := storage.Config{DictFile: dictFile, IdfFile: idfFile, Language: "en"}
+		if _, err := storage.NewSeparatedMemoryStorage(bundledModel, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("unshared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			// new names are new dictionaries to the registry
+			b.StopTimer()
+			fresh := []string{
+				filepath.Join(dir, fmt.Sprintf("dict-%d-%d.txt", b.N, i)),
+				filepath.Join(dir, fmt.Sprintf("idf-%d-%d.txt", b.N, i)),
+			}
+			for j, file := range []string{dictFile, idfFile} {
+				if err := os.Link(file, fresh[j]); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StartTimer()
+
+			load(b, fresh[0], fresh[1])
+		}
+	})
+
+	b.Run("shared", func(b *testing.B) {
+		load(b, dictFile, idfFile)
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			load(b, dictFile, idfFile)
+		}
+	})
+}
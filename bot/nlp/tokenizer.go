@@ -0,0 +1,141 @@
+package nlp
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"unicode"
+)
+
+type (
+	// Tokenizer splits text into the terms it is indexed and searched by.
+	Tokenizer interface {
+		Tokenize(text string) []string
+	}
+
+	// EnglishTokenizer lowercases English text, splits it into words and
+	// numbers with their units, drops the stop words and stems the rest.
+	EnglishTokenizer struct {
+		stopWords map[string]bool
+	}
+)
+
+var englishStopWords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "am", "an", "and",
+	"any", "are", "as", "at", "be", "because", "been", "before", "being", "below",
+	"between", "both", "but", "by", "can", "could", "did", "do", "does", "doing",
+	"down", "during", "each", "few", "for", "from", "further", "had", "has", "have",
+	"having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how",
+	"i", "if", "in", "into", "is", "it", "its", "itself", "just", "me", "more",
+	"most", "my", "myself", "no", "nor", "not", "now", "of", "off", "on", "once",
+	"only", "or", "other", "our", "ours", "ourselves", "out", "over", "own", "same",
+	"she", "should", "so", "some", "such", "than", "that", "the", "their", "theirs",
+	"them", "themselves", "then", "there", "these", "they", "this", "those",
+	"through", "to", "too", "under", "until", "up", "very", "was", "we", "were",
+	"what", "when", "where", "which", "while", "who", "whom", "why", "will", "with",
+	"would", "you", "your", "yours", "yourself", "yourselves",
+}
+
+// NewEnglishTokenizer creates an EnglishTokenizer that drops the built-in
+// English stop words and the given ones.
+func NewEnglishTokenizer(stopWords ...string) *EnglishTokenizer {
+	set := createWordSet(englishStopWords)
+	for _, word := range stopWords {
+		set[strings.ToLower(word)] = true
+	}
+
+	return &EnglishTokenizer{
+		stopWords: set,
+	}
+}
+
+// Tokenize returns the stemmed terms of text in order, duplicates included.
+// Numbers keep their units and separators, so "0-10V" and "4.2" stay whole.
+func (tokenizer *EnglishTokenizer) Tokenize(text string) []string {
+	var terms []string
+	for _, word := range splitWords(strings.ToLower(text)) {
+		if tokenizer.stopWords[word] {
+			continue
+		}
+		terms = append(terms, Stem(word))
+	}
+
+	return terms
+}
+
+// ReadWords reads a word list with one word per line. Blank lines, comments
+// starting with # and the "count:" lines of generated stop word files are
+// skipped.
+func ReadWords(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if len(word) == 0 || strings.HasPrefix(word, "#") || strings.HasSuffix(word, ":") {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	return words, scanner.Err()
+}
+
+// splitWords splits lowercased text into words. Apostrophes are dropped with
+// a possessive s, so "periMICA's" is "perimica". '.', '-', '/' and ',' join
+// the parts of a word containing digits, like 0-10v, 192.168.0.1 or 4,2. A
+// number keeps its sign and a degree unit, so "-40°c" is one word.
+func splitWords(text string) []string {
+	var words []string
+	runes := []rune(text)
+	var word []rune
+	hasDigit := false
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+		}
+		word = word[:0]
+		hasDigit = false
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsLetter(r):
+			word = append(word, r)
+		case unicode.IsDigit(r):
+			word = append(word, r)
+			hasDigit = true
+		case r == '\'' || r == '’':
+			if i+1 < len(runes) && runes[i+1] == 's' && (i+2 == len(runes) || !isWordRune(runes[i+2])) {
+				i++
+			}
+		case r == '-' && len(word) == 0 && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			word = append(word, r)
+		case r == '°' && hasDigit && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			word = append(word, r)
+		case r == '.' || r == '-' || r == '/' || r == ',':
+			if len(word) > 0 && i+1 < len(runes) && isWordRune(runes[i+1]) &&
+				(hasDigit || unicode.IsDigit(runes[i+1])) && !(r == ',' && !hasDigit) {
+				word = append(word, r)
+			} else {
+				flush()
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
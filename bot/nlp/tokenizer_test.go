@@ -0,0 +1,56 @@
+package nlp
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		text  string
+		words []string
+	}{
+		{"", nil},
+		{"0-10v", []string{"0-10v"}},
+		{"ip 192.168.0.1.", []string{"ip", "192.168.0.1"}},
+		{"the perimica's app", []string{"the", "perimica", "app"}},
+		{"the perimica’s app", []string{"the", "perimica", "app"}},
+		{"it's", []string{"it"}},
+		{"don't", []string{"dont"}},
+		{"4,2 or 4.2 mm", []string{"4,2", "or", "4.2", "mm"}},
+		{"red, green", []string{"red", "green"}},
+		{"input/output", []string{"input", "output"}},
+		{"rs-485 and 24v/dc", []string{"rs-485", "and", "24v/dc"}},
+		{"from -40°c to 85°c", []string{"from", "-40°c", "to", "85°c"}},
+		{"-40 °c", []string{"-40", "c"}},
+		{"10 - 20", []string{"10", "20"}},
+		{"a -b", []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		if got := splitWords(test.text); !slices.Equal(got, test.words) {
+			t.Errorf("splitWords(%q) = %q, want %q", test.text, got, test.words)
+		}
+	}
+}
+
+func TestEnglishTokenizer(t *testing.T) {
+	tokenizer := NewEnglishTokenizer("gateway")
+
+	tests := []struct {
+		text  string
+		terms []string
+	}{
+		{"", nil},
+		{"What are the temperatures?", []string{"temperatur"}},
+		{"The temperature of the periMICA's CPU", []string{"temperatur", "perimica", "cpu"}},
+		{"How do I wire a periNODE 0-10V?", []string{"wire", "perinod", "0-10v"}},
+		{"Does it work at -40°C?", []string{"work", "-40°c"}},
+		{"The Gateway forwards data to 192.168.0.1", []string{"forward", "data", "192.168.0.1"}},
+		{"sensors, sensors", []string{"sensor", "sensor"}},
+	}
+	for _, test := range tests {
+		if got := tokenizer.Tokenize(test.text); !slices.Equal(got, test.terms) {
+			t.Errorf("Tokenize(%q) = %q, want %q", test.text, got, test.terms)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package bot
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golangChatBot/bot/adapters/logic"
+)
+
+const (
+	defaultContextMemory = 2
+	defaultContextBoost  = 0.1
+	defaultMaxHistory    = 50
+	defaultSessionIdle   = 30 * time.Minute
+	sessionSweepInterval = time.Minute
+)
+
+type (
+	// Turn is one question/answer exchange within a session.
+	Turn struct {
+		Question string
+		Answers  []logic.Answer
+		Time     time.Time
+	}
+
+	// SessionSettings holds the per-session conversation options.
+	SessionSettings struct {
+		// ContextEnabled turns context carry-over between turns on or off.
+		ContextEnabled bool
+		// ContextMemory is the number of turns a context category stays active.
+		ContextMemory int
+		// ContextFilter narrows the candidates to the active context categories
+		// instead of boosting them.
+		ContextFilter bool
+		// ContextBoost is added to the score of candidates in an active context
+		// category, 0 means the default.
+		ContextBoost float32
+		// MaxHistory bounds the number of turns kept, 0 means the default.
+		MaxHistory int
+		// Tops limits the number of answers returned, 0 means no limit.
+		Tops int
+	}
+
+	// Session keeps the state of one conversation across calls to ChatBot.Respond.
+	Session struct {
+		ID       string
+		Settings SessionSettings
+
+		lock       sync.Mutex
+		history    []Turn
+		categories map[string]int
+		// lastActive is the time of the last activity in unix nanoseconds, it
+		// is read by the sweeps of the store without taking the lock.
+		lastActive atomic.Int64
+	}
+
+	// SessionStore hands out sessions by id and drops the ones idle for too
+	// long. Expired sessions are swept at most once per minute, or per idle
+	// time if shorter, by the Get calls, and replaced if asked for before.
+	SessionStore struct {
+		settings   SessionSettings
+		idle       time.Duration
+		sweepEvery time.Duration
+		lock       sync.Mutex
+		sessions   map[string]*Session
+		lastSweep  time.Time
+	}
+)
+
+// DefaultSessionSettings returns the settings the CLI uses by default.
+func DefaultSessionSettings() SessionSettings {
+	return SessionSettings{
+		ContextEnabled: true,
+		ContextMemory:  defaultContextMemory,
+		MaxHistory:     defaultMaxHistory,
+	}
+}
+
+func NewSession(id string, settings SessionSettings) *Session {
+	session := &Session{
+		ID:         id,
+		Settings:   settings,
+		categories: make(map[string]int),
+	}
+	session.touch(time.Now())
+
+	return session
+}
+
+// ActiveCategories returns the context categories that are still alive, sorted.
+func (session *Session) ActiveCategories() []string {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	return session.activeCategories()
+}
+
+// Categories returns a copy of the context categories with their remaining ages.
+func (session *Session) Categories() map[string]int {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	categories := make(map[string]int, len(session.categories))
+	for category, age := range session.categories {
+		categories[category] = age
+	}
+
+	return categories
+}
+
+// History returns a copy of the turns recorded so far.
+func (session *Session) History() []Turn {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	history := make([]Turn, len(session.history))
+	copy(history, session.history)
+	return history
+}
+
+// Reset clears the history and the context categories.
+func (session *Session) Reset() {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+
+	session.history = nil
+	session.categories = make(map[string]int)
+}
+
+func (session *Session) activeCategories() []string {
+	var categories []string
+	for category, age := range session.categories {
+		if age > 0 {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	return categories
+}
+
+func (session *Session) ageCategories() {
+	for category, age := range session.categories {
+		if age > 1 {
+			session.categories[category] = age - 1
+		} else {
+			delete(session.categories, category)
+		}
+	}
+}
+
+func (session *Session) contextMemory() int {
+	if session.Settings.ContextMemory > 0 {
+		return session.Settings.ContextMemory
+	}
+
+	return defaultContextMemory
+}
+
+func (session *Session) scope(categories []string) logic.ProcessOption {
+	if session.Settings.ContextFilter {
+		return logic.WithCategoryFilter(categories...)
+	}
+
+	boost := session.Settings.ContextBoost
+	if boost == 0 {
+		boost = defaultContextBoost
+	}
+
+	return logic.WithCategoryBoost(boost, categories...)
+}
+
+func (session *Session) record(turn Turn) {
+	session.history = append(session.history, turn)
+
+	maxHistory := session.Settings.MaxHistory
+	if maxHistory <= 0 {
+		maxHistory = defaultMaxHistory
+	}
+	if len(session.history) > maxHistory {
+		session.history = session.history[len(session.history)-maxHistory:]
+	}
+}
+
+// expired tells whether the session was idle for longer than idle at now.
+func (session *Session) expired(now time.Time, idle time.Duration) bool {
+	return now.Sub(time.Unix(0, session.lastActive.Load())) > idle
+}
+
+func (session *Session) touch(now time.Time) {
+	session.lastActive.Store(now.UnixNano())
+}
+
+func (session *Session) touchCategories(keywords []string, text string) {
+	textLower := strings.ToLower(text)
+	for _, keyword := range keywords {
+		if strings.Contains(textLower, strings.ToLower(keyword)) {
+			session.categories[keyword] = session.contextMemory()
+		}
+	}
+}
+
+// NewSessionStore creates a store whose sessions use the given settings and
+// expire after idle without activity, 0 means the default of 30 minutes.
+func NewSessionStore(settings SessionSettings, idle time.Duration) *SessionStore {
+	if idle <= 0 {
+		idle = defaultSessionIdle
+	}
+
+	sweepEvery := sessionSweepInterval
+	if idle < sweepEvery {
+		sweepEvery = idle
+	}
+
+	return &SessionStore{
+		settings:   settings,
+		idle:       idle,
+		sweepEvery: sweepEvery,
+		sessions:   make(map[string]*Session),
+		lastSweep:  time.Now(),
+	}
+}
+
+// Get returns the session with the given id, creating it if necessary, or
+// if the one stored has expired.
+func (store *SessionStore) Get(id string) *Session {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(store.lastSweep) >= store.sweepEvery {
+		store.sweep(now)
+	}
+
+	session, ok := store.sessions[id]
+	if !ok || session.expired(now, store.idle) {
+		session = NewSession(id, store.settings)
+		store.sessions[id] = session
+	}
+	session.touch(now)
+
+	return session
+}
+
+// Transient returns a new session with the settings of the store that the
+// store does not keep, for requests that name no session.
+func (store *SessionStore) Transient() *Session {
+	session := NewSession("", store.settings)
+	session.touch(time.Now())
+
+	return session
+}
+
+// Len returns the number of sessions kept, expired ones not swept yet included.
+func (store *SessionStore) Len() int {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	return len(store.sessions)
+}
+
+// Remove drops the session with the given id.
+func (store *SessionStore) Remove(id string) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	delete(store.sessions, id)
+}
+
+// sweep drops the expired sessions. The store lock must be held.
+func (store *SessionStore) sweep(now time.Time) {
+	for id, session := range store.sessions {
+		if session.expired(now, store.idle) {
+			delete(store.sessions, id)
+		}
+	}
+	store.lastSweep = now
+}
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golangChatBot/bot/adapters/logic"
+)
+
+// blockingAdapter answers every question once release is closed.
+type blockingAdapter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (adapter *blockingAdapter) CanProcess(string) bool {
+	return true
+}
+
+func (adapter *blockingAdapter) Process(text string, _ ...logic.ProcessOption) []logic.Answer {
+	if adapter.started != nil {
+		adapter.started <- struct{}{}
+	}
+	<-adapter.release
+
+	return []logic.Answer{{Content: "answer to " + text, Confidence: 1}}
+}
+
+func (adapter *blockingAdapter) SetVerbose() {
+}
+
+func TestSessionStoreExpires(t *testing.T) {
+	store := NewSessionStore(DefaultSessionSettings(), 20*time.Millisecond)
+	first := store.Get("a")
+	if store.Get("a") != first {
+		t.Fatal("Get returned a new session for a live one")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	store.Get("b")
+	if n := store.Len(); n != 1 {
+		t.Fatalf("got %d sessions after the sweep, want 1", n)
+	}
+	if store.Get("a") == first {
+		t.Fatal("Get returned an expired session")
+	}
+}
+
+func TestSessionStoreKeepsActiveSessions(t *testing.T) {
+	store := NewSessionStore(DefaultSessionSettings(), 50*time.Millisecond)
+	session := store.Get("a")
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if store.Get("a") != session {
+			t.Fatalf("the session expired while in use after %d gets", i)
+		}
+	}
+}
+
+func TestRespondDoesNotHoldSessionLock(t *testing.T) {
+	adapter := &blockingAdapter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	chatbot := &ChatBot{LogicAdapter: adapter}
+	session := NewSession("a", DefaultSessionSettings())
+
+	done := make(chan error)
+	go func() {
+		_, err := chatbot.Respond(context.Background(), session, "what is a gateway?")
+		done <- err
+	}()
+	<-adapter.started
+
+	read := make(chan struct{})
+	go func() {
+		session.History()
+		session.ActiveCategories()
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(time.Second):
+		t.Fatal("reading the session blocked while a question was answered")
+	}
+
+	close(adapter.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if history := session.History(); len(history) != 1 {
+		t.Fatalf("got %d turns, want 1", len(history))
+	}
+}
+
+func TestRespondConcurrently(t *testing.T) {
+	adapter := &blockingAdapter{release: make(chan struct{})}
+	close(adapter.release)
+	chatbot := &ChatBot{
+		LogicAdapter: adapter,
+		Keywords:     []string{"gateway", "sensor"},
+	}
+	store := NewSessionStore(DefaultSessionSettings(), time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				session := store.Get(fmt.Sprintf("session %d", (i+j)%4))
+				if _, err := chatbot.Respond(context.Background(), session, "is the gateway a sensor?"); err != nil {
+					t.Error(err)
+					return
+				}
+				session.History()
+				session.Categories()
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestTransientSessionsAreNotKept(t *testing.T) {
+	adapter := &blockingAdapter{release: make(chan struct{})}
+	close(adapter.release)
+	chatbot := &ChatBot{LogicAdapter: adapter}
+	store := NewSessionStore(DefaultSessionSettings(), 0)
+
+	for i := 0; i < 100; i++ {
+		if _, err := chatbot.Respond(context.Background(), store.Transient(), "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.Len() != 0 {
+		t.Fatalf("the store keeps %d sessions, want none", store.Len())
+	}
+}
@@ -19,7 +19,8 @@ type (
 	}
 
 	CorpusTrainer struct {
-		storage storage.StorageAdapter
+		storage     storage.StorageAdapter
+		incremental bool
 	}
 )
 
@@ -43,6 +44,13 @@ func (trainer *ConversationTrainer) Train(data interface{}) error {
 		return errors.New("ConversationTrainer.Train needs arguments to be []string")
 	}
 
+	trainer.train(sentences, "")
+	return nil
+}
+
+// train stores every sentence as the response to the previous one and,
+// if category is not empty, tags the previous sentence with it.
+func (trainer *ConversationTrainer) train(sentences []string, category string) {
 	var history string
 	for _, sentence := range sentences {
 		sentence = strings.TrimSpace(sentence)
@@ -54,12 +62,13 @@ func (trainer *ConversationTrainer) Train(data interface{}) error {
 			responses := trainer.getOrCreate(history)
 			responses[sentence] += 1
 			trainer.storage.Update(history, responses)
+			if len(category) > 0 {
+				trainer.storage.AddCategory(history, category)
+			}
 		}
 
 		history = sentence
 	}
-
-	return nil
 }
 
 func NewCorpusTrainer(storage storage.StorageAdapter) *CorpusTrainer {
@@ -68,6 +77,15 @@ func NewCorpusTrainer(storage storage.StorageAdapter) *CorpusTrainer {
 	}
 }
 
+// NewIncrementalCorpusTrainer returns a CorpusTrainer that only indexes the
+// questions not yet known to storage instead of rebuilding all indexes.
+func NewIncrementalCorpusTrainer(storage storage.StorageAdapter) *CorpusTrainer {
+	return &CorpusTrainer{
+		storage:     storage,
+		incremental: true,
+	}
+}
+
 func (trainer *CorpusTrainer) Train(data interface{}) error {
 	files, ok := data.([]string)
 	if !ok {
@@ -84,16 +102,19 @@ func (trainer *CorpusTrainer) Train(data interface{}) error {
 
 	fmt.Println("Creating Q/A mappings...")
 
-	for _, convs := range corpora {
+	for category, convs := range corpora {
 		for _, conv := range convs {
-			if err := convTrainer.Train(conv); err != nil {
-				return err
-			}
+			convTrainer.train(conv, category)
 		}
 	}
 
-	fmt.Println("Building indexes...")
+	if trainer.incremental {
+		fmt.Println("Updating indexes...")
+		trainer.storage.UpdateIndex()
+	} else {
+		fmt.Println("Building indexes...")
+		trainer.storage.BuildIndex()
+	}
 
-	trainer.storage.BuildIndex()
 	return nil
 }
@@ -8,141 +8,167 @@ import (
 	"strings"
 
 	"path/filepath"
-
-	"github.com/agnivade/levenshtein"
 )
 
-var (
-	customDictionary map[string]string
-	vocabulary       []string
-	wordFrequency    map[string]int
-	maxEditDistance  = 3
+const maxEditDistance = 3
+
+// The reasons of a Correction.
+const (
+	// ReasonCustomTerm is a word replaced as the custom dictionary says.
+	ReasonCustomTerm CorrectionReason = "custom term"
+	// ReasonVocabulary is an unknown word replaced by the closest vocabulary word.
+	ReasonVocabulary CorrectionReason = "vocabulary match"
+	// ReasonFrequency is an unknown word replaced by the most frequent of the
+	// closest known words.
+	ReasonFrequency CorrectionReason = "frequency pick"
 )
 
-type Config struct {
-	CustomDictionaryFile string `yaml:"custom_dictionary_file"`
-	VocabularyFile       string `yaml:"vocabulary_file"`
-	WordFrequencyFile    string `yaml:"word_frequency_file"`
-}
+type (
+	Config struct {
+		CustomDictionaryFile string `yaml:"custom_dictionary_file"`
+		VocabularyFile       string `yaml:"vocabulary_file"`
+		WordFrequencyFile    string `yaml:"word_frequency_file"`
+	}
+
+	// CorrectionReason tells why a word was corrected.
+	CorrectionReason string
+
+	// Correction is a word Correct replaced, with the reason it did.
+	Correction struct {
+		Original  string
+		Corrected string
+		Reason    CorrectionReason
+	}
 
-func Initialize(config Config) error {
-	err := loadCustomDictionary(config.CustomDictionaryFile)
+	// Corrector corrects the spelling of texts with its own dictionaries, it
+	// is safe for concurrent use.
+	Corrector struct {
+		customDictionary map[string]string
+		vocabulary       map[string]bool
+		wordFrequency    map[string]int
+		spelling         *spellIndex
+	}
+)
+
+// NewCorrector loads the dictionaries of config. A missing word frequency
+// file only leaves the corrector without frequent words.
+func NewCorrector(config Config) (*Corrector, error) {
+	customDictionary, err := loadCustomDictionary(config.CustomDictionaryFile)
 	if err != nil {
-		return fmt.Errorf("error loading custom dictionary: %w", err)
+		return nil, fmt.Errorf("error loading custom dictionary: %w", err)
 	}
 
-	err = loadVocabulary(config.VocabularyFile)
+	vocabulary, err := loadVocabulary(config.VocabularyFile)
 	if err != nil {
-		return fmt.Errorf("error loading vocabulary: %w", err)
+		return nil, fmt.Errorf("error loading vocabulary: %w", err)
 	}
 
-	err = loadWordFrequency(config.WordFrequencyFile)
+	wordFrequency, err := loadWordFrequency(config.WordFrequencyFile)
 	if err != nil {
 		fmt.Printf("Warning: Could not load word frequency data: %v\n", err)
 		wordFrequency = make(map[string]int)
 	}
 
-	return nil
-}
-
-func CorrectInput(input string) string {
-	input = correctCustomTerms(input)
-	input = correctSpelling(input)
-	return input
-}
-
-func correctCustomTerms(input string) string {
-	words := strings.Fields(input)
-	for i, word := range words {
-		lowerWord := strings.ToLower(word)
-		if corrected, exists := customDictionary[lowerWord]; exists {
-			words[i] = corrected
-		}
+	known := make(map[string]bool, len(vocabulary))
+	for _, word := range vocabulary {
+		known[strings.ToLower(word)] = true
 	}
-	return strings.Join(words, " ")
+
+	return &Corrector{
+		customDictionary: customDictionary,
+		vocabulary:       known,
+		wordFrequency:    wordFrequency,
+		// index the words once, so that suggestions don't scan all of them
+		spelling: newSpellIndex(vocabulary, wordFrequency, maxEditDistance),
+	}, nil
 }
 
-func correctSpelling(input string) string {
-	words := strings.Fields(input)
-	for i, word := range words {
-		lowerWord := strings.ToLower(word)
-		if _, exists := customDictionary[lowerWord]; exists {
-			continue
-		}
-		if containsWord(vocabulary, word) {
+// Correct returns text with its custom terms replaced and its unknown words
+// spelled as the closest known ones, and the corrections it made in order.
+// The spacing, punctuation and case of text are kept, and so are the tokens
+// that are not words, like numbers, units, versions and URLs.
+func (corrector *Corrector) Correct(text string) (string, []Correction) {
+	var corrected strings.Builder
+	var corrections []Correction
+	for _, token := range tokenize(text) {
+		if !token.word {
+			corrected.WriteString(token.text)
 			continue
 		}
 
-		if _, exists := wordFrequency[lowerWord]; exists {
+		correction, ok := corrector.correctWord(token.text)
+		if !ok {
+			corrected.WriteString(token.text)
 			continue
 		}
-		suggestions := getSuggestions(lowerWord)
-		if len(suggestions) > 0 {
-			words[i] = suggestions[0]
-		}
+		corrected.WriteString(correction.Corrected)
+		corrections = append(corrections, correction)
 	}
-	return strings.Join(words, " ")
+
+	return corrected.String(), corrections
 }
 
-func getSuggestions(word string) []string {
-	candidates := []string{}
-	minDistance := maxEditDistance + 1
-	for _, vocabWord := range vocabulary {
-		distance := levenshtein.ComputeDistance(word, strings.ToLower(vocabWord))
-		if distance <= maxEditDistance {
-			if distance < minDistance {
-				minDistance = distance
-				candidates = []string{vocabWord}
-			} else if distance == minDistance {
-				candidates = append(candidates, vocabWord)
-			}
-		}
+// correctWord returns the correction of word, false if it is spelled right.
+func (corrector *Corrector) correctWord(word string) (Correction, bool) {
+	lowerWord := strings.ToLower(word)
+	correction := Correction{Original: word}
+	if corrected, exists := corrector.customDictionary[lowerWord]; exists {
+		correction.Corrected = matchCase(word, corrected)
+		correction.Reason = ReasonCustomTerm
+		return correction, correction.Corrected != word
 	}
-
-	for freqWord := range wordFrequency {
-		distance := levenshtein.ComputeDistance(word, freqWord)
-		if distance <= maxEditDistance {
-			if distance < minDistance {
-				minDistance = distance
-				candidates = []string{freqWord}
-			} else if distance == minDistance {
-				candidates = append(candidates, freqWord)
-			}
-		}
+	if corrector.isKnown(lowerWord) {
+		return correction, false
 	}
 
-	if len(candidates) > 1 {
-		highestFreq := -1
-		bestCandidate := ""
-		for _, candidate := range candidates {
-			freq := getWordFrequency(candidate)
-			if freq > highestFreq {
-				highestFreq = freq
-				bestCandidate = candidate
-			}
-		}
-		return []string{bestCandidate}
+	suggestion, ok := corrector.spelling.suggest(lowerWord)
+	if !ok {
+		return correction, false
+	}
+	correction.Corrected = matchCase(word, suggestion.word)
+	correction.Reason = ReasonFrequency
+	if suggestion.vocabulary {
+		correction.Reason = ReasonVocabulary
 	}
 
-	return candidates
+	return correction, correction.Corrected != word
 }
 
-func getWordFrequency(word string) int {
-	if freq, exists := wordFrequency[strings.ToLower(word)]; exists {
-		return freq
+// isKnown tells whether word is in the vocabulary or has a frequency. The
+// word lists have no apostrophes, so a contraction is known if it is without
+// them, or if the word before the apostrophe is.
+func (corrector *Corrector) isKnown(word string) bool {
+	if corrector.vocabulary[word] {
+		return true
+	}
+	if _, exists := corrector.wordFrequency[word]; exists {
+		return true
+	}
+
+	if i := strings.IndexFunc(word, isApostrophe); i > 0 {
+		return corrector.isKnown(strings.Map(func(r rune) rune {
+			if isApostrophe(r) {
+				return -1
+			}
+			return r
+		}, word)) || corrector.isKnown(word[:i])
 	}
-	return 1
+	return false
 }
 
-func loadCustomDictionary(filePath string) error {
-	customDictionary = make(map[string]string)
+func (correction Correction) String() string {
+	return fmt.Sprintf("%s -> %s (%s)", correction.Original, correction.Corrected, correction.Reason)
+}
+
+func loadCustomDictionary(filePath string) (map[string]string, error) {
+	customDictionary := make(map[string]string)
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	file, err := os.Open(absPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
@@ -157,25 +183,26 @@ func loadCustomDictionary(filePath string) error {
 		if len(parts) != 2 {
 			continue
 		}
-		misspelling := strings.TrimSpace(parts[0])
+		// words are looked up lowercased
+		misspelling := strings.ToLower(strings.TrimSpace(parts[0]))
 		correctTerm := strings.TrimSpace(parts[1])
 		customDictionary[misspelling] = correctTerm
 	}
 	if err := scanner.Err(); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return customDictionary, nil
 }
 
-func loadVocabulary(filePath string) error {
-	vocabulary = []string{}
+func loadVocabulary(filePath string) ([]string, error) {
+	vocabulary := []string{}
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	file, err := os.Open(absPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
@@ -188,20 +215,20 @@ func loadVocabulary(filePath string) error {
 		vocabulary = append(vocabulary, line)
 	}
 	if err := scanner.Err(); err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return vocabulary, nil
 }
 
-func loadWordFrequency(filePath string) error {
-	wordFrequency = make(map[string]int)
+func loadWordFrequency(filePath string) (map[string]int, error) {
+	wordFrequency := make(map[string]int)
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	file, err := os.Open(absPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
@@ -222,17 +249,7 @@ func loadWordFrequency(filePath string) error {
 		wordFrequency[word] = freq
 	}
 	if err := scanner.Err(); err != nil {
-		return err
-	}
-	return nil
-}
-
-func containsWord(slice []string, word string) bool {
-	lowerWord := strings.ToLower(word)
-	for _, str := range slice {
-		if strings.ToLower(str) == lowerWord {
-			return true
-		}
+		return nil, err
 	}
-	return false
+	return wordFrequency, nil
 }
@@ -0,0 +1,85 @@
+package nlp
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestCorrector returns a Corrector with a small dictionary, the way
+// NewCorrector builds it from the files.
+func newTestCorrector() *Corrector {
+	customDictionary := map[string]string{
+		"mica":     "periMICA",
+		"perimica": "periMICA",
+		"wifi":     "Wi-Fi",
+	}
+	vocabulary := []string{"gateway", "sensor", "firewall", "periNODE"}
+	wordFrequency := map[string]int{
+		"the": 2000, "is": 1000, "where": 500, "how": 500, "do": 400, "i": 400,
+		"it": 300, "reset": 50, "down": 40, "fine": 30, "with": 200, "use": 100,
+		"visit": 20, "at": 300, "works": 30, "and": 1000, "version": 20, "mail": 10,
+	}
+
+	known := make(map[string]bool, len(vocabulary))
+	for _, word := range vocabulary {
+		known[strings.ToLower(word)] = true
+	}
+
+	return &Corrector{
+		customDictionary: customDictionary,
+		vocabulary:       known,
+		wordFrequency:    wordFrequency,
+		spelling:         newSpellIndex(vocabulary, wordFrequency, maxEditDistance),
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	corrector := newTestCorrector()
+
+	tests := []struct {
+		text        string
+		want        string
+		corrections []Correction
+	}{
+		{"", "", nil},
+		{"where is the gateway", "where is the gateway", nil},
+		// custom terms, next to punctuation too
+		{"mica?", "periMICA?", []Correction{{"mica", "periMICA", ReasonCustomTerm}}},
+		{"PeriMICA,", "periMICA,", []Correction{{"PeriMICA", "periMICA", ReasonCustomTerm}}},
+		{"the periMICA", "the periMICA", nil},
+		{"use WIFI", "use Wi-Fi", []Correction{{"WIFI", "Wi-Fi", ReasonCustomTerm}}},
+		// unknown words
+		{"where is the gatway", "where is the gateway", []Correction{{"gatway", "gateway", ReasonVocabulary}}},
+		{"how do I resett it", "how do I reset it", []Correction{{"resett", "reset", ReasonFrequency}}},
+		{"the perinod", "the periNODE", []Correction{{"perinod", "periNODE", ReasonVocabulary}}},
+		// the case of the original word
+		{"Gatway", "Gateway", []Correction{{"Gatway", "Gateway", ReasonVocabulary}}},
+		{"GATWAY", "GATEWAY", []Correction{{"GATWAY", "GATEWAY", ReasonVocabulary}}},
+		{"Where IS The gateway", "Where IS The gateway", nil},
+		// spacing and punctuation
+		{"  the   gatway\tis  down!\n", "  the   gateway\tis  down!\n", []Correction{{"gatway", "gateway", ReasonVocabulary}}},
+		{"(sensr) and firewal...", "(sensor) and firewall...", []Correction{
+			{"sensr", "sensor", ReasonVocabulary},
+			{"firewal", "firewall", ReasonVocabulary},
+		}},
+		{"it's fine", "it's fine", nil},
+		// numbers, units, addresses and versions are not words
+		{"it works at -40°C", "it works at -40°C", nil},
+		{"the 4GB version", "the 4GB version", nil},
+		{"visit https://perimica.exmple.com/docs", "visit https://perimica.exmple.com/docs", nil},
+		{"visit www.exmple.com and exmple.org.", "visit www.exmple.com and exmple.org.", nil},
+		{"the gateway is 192.168.0.1", "the gateway is 192.168.0.1", nil},
+		{"use v2.3.1-rc1 with fw1.2", "use v2.3.1-rc1 with fw1.2", nil},
+		{"mail sensr@exmple.com", "mail sensr@exmple.com", nil},
+	}
+	for _, test := range tests {
+		got, corrections := corrector.Correct(test.text)
+		if got != test.want {
+			t.Errorf("Correct(%q) = %q, want %q", test.text, got, test.want)
+		}
+		if !slices.Equal(corrections, test.corrections) {
+			t.Errorf("Correct(%q) corrected %v, want %v", test.text, corrections, test.corrections)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package nlp
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/agnivade/levenshtein"
+)
+
+// prefixLength is the number of leading runes of a word that are indexed,
+// longer words are told apart by the edit distance computed on lookup.
+const prefixLength = 7
+
+type (
+	// spellIndex finds the words within maxEditDistance of a word without
+	// comparing it to the whole dictionary, the way SymSpell does. Every word
+	// is indexed under the strings its prefix turns into by deleting up to
+	// maxEditDistance runes. Two words within the distance always share one
+	// of them, so a word is only compared to the words indexed under the
+	// deletes of its own prefix.
+	//
+	// The postings are kept in flat arrays sorted by the hashes of the
+	// deletes: the ids of the words under hashes[i] are
+	// ids[starts[i]:starts[i+1]].
+	spellIndex struct {
+		entries     []spellEntry
+		hashes      []uint64
+		starts      []int32
+		ids         []int32
+		maxDistance int
+	}
+
+	deletePosting struct {
+		hash uint64
+		id   int32
+	}
+
+	spellEntry struct {
+		// word is suggested as it is, key is the lowercased word compared
+		// and length its number of runes
+		word       string
+		key        string
+		length     int
+		frequency  int
+		vocabulary bool
+	}
+)
+
+// newSpellIndex indexes the vocabulary words, then the words with a
+// frequency. Between suggestions as close and as frequent, the vocabulary
+// words come first, then the words in alphabetical order.
+func newSpellIndex(vocabulary []string, frequencies map[string]int, maxDistance int) *spellIndex {
+	index := &spellIndex{
+		entries:     make([]spellEntry, 0, len(vocabulary)+len(frequencies)),
+		maxDistance: maxDistance,
+	}
+
+	var postings []deletePosting
+	for _, word := range vocabulary {
+		postings = index.add(postings, word, frequencyOf(frequencies, word), true)
+	}
+
+	frequent := make([]string, 0, len(frequencies))
+	for word := range frequencies {
+		frequent = append(frequent, word)
+	}
+	sort.Slice(frequent, func(i, j int) bool {
+		if frequencies[frequent[i]] != frequencies[frequent[j]] {
+			return frequencies[frequent[i]] > frequencies[frequent[j]]
+		}
+		return frequent[i] < frequent[j]
+	})
+	for _, word := range frequent {
+		postings = index.add(postings, word, frequencies[word], false)
+	}
+
+	slices.SortFunc(postings, func(a, b deletePosting) int {
+		if a.hash != b.hash {
+			return cmp.Compare(a.hash, b.hash)
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+	index.ids = make([]int32, 0, len(postings))
+	for i, posting := range postings {
+		if i > 0 && posting.hash == postings[i-1].hash {
+			// repeated runes give the same deletes more than once
+			if posting.id != postings[i-1].id {
+				index.ids = append(index.ids, posting.id)
+			}
+			continue
+		}
+		index.hashes = append(index.hashes, posting.hash)
+		index.starts = append(index.starts, int32(len(index.ids)))
+		index.ids = append(index.ids, posting.id)
+	}
+	index.starts = append(index.starts, int32(len(index.ids)))
+
+	return index
+}
+
+// suggest returns the entry of the closest word to word within the maximum
+// distance, the most frequent one between the closest ones, false if there
+// is none.
+func (index *spellIndex) suggest(word string) (spellEntry, bool) {
+	word = strings.ToLower(word)
+	length := len([]rune(word))
+
+	best := -1
+	bestDistance := index.maxDistance + 1
+	seen := make(map[int32]bool)
+	visitDeletes(prefix(word), index.maxDistance, func(hash uint64) {
+		for _, id := range index.postings(hash) {
+			entry := index.entries[id]
+			difference := entry.length - length
+			if difference > index.maxDistance || -difference > index.maxDistance || seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			distance := levenshtein.ComputeDistance(word, entry.key)
+			if distance > index.maxDistance {
+				continue
+			}
+			if distance < bestDistance ||
+				(distance == bestDistance && (entry.frequency > index.entries[best].frequency ||
+					entry.frequency == index.entries[best].frequency && id < int32(best))) {
+				best = int(id)
+				bestDistance = distance
+			}
+		}
+	})
+
+	if best < 0 {
+		return spellEntry{}, false
+	}
+
+	return index.entries[best], true
+}
+
+// add adds word to the entries and the postings of the deletes of its prefix
+// to postings.
+func (index *spellIndex) add(postings []deletePosting, word string, frequency int, vocabulary bool) []deletePosting {
+	id := int32(len(index.entries))
+	key := strings.ToLower(word)
+	index.entries = append(index.entries, spellEntry{
+		word:       word,
+		key:        key,
+		length:     len([]rune(key)),
+		frequency:  frequency,
+		vocabulary: vocabulary,
+	})
+
+	visitDeletes(prefix(key), index.maxDistance, func(hash uint64) {
+		postings = append(postings, deletePosting{
+			hash: hash,
+			id:   id,
+		})
+	})
+
+	return postings
+}
+
+// postings returns the ids of the words indexed under the delete hash.
+func (index *spellIndex) postings(hash uint64) []int32 {
+	i := sort.Search(len(index.hashes), func(i int) bool {
+		return index.hashes[i] >= hash
+	})
+	if i == len(index.hashes) || index.hashes[i] != hash {
+		return nil
+	}
+
+	return index.ids[index.starts[i]:index.starts[i+1]]
+}
+
+// visitDeletes calls visit with the hashes of runes and of the strings made by
+// deleting up to maxDistance of them. The strings themselves are never built,
+// their runes are hashed skipping the deleted ones.
+func visitDeletes(runes []rune, maxDistance int, visit func(uint64)) {
+	skipped := make([]bool, len(runes))
+	var walk func(from, left int)
+	walk = func(from, left int) {
+		visit(hashSkipping(runes, skipped))
+		if left == 0 {
+			return
+		}
+		for i := from; i < len(runes); i++ {
+			skipped[i] = true
+			walk(i+1, left-1)
+			skipped[i] = false
+		}
+	}
+	walk(0, maxDistance)
+}
+
+func frequencyOf(frequencies map[string]int, word string) int {
+	if frequency, ok := frequencies[strings.ToLower(word)]; ok {
+		return frequency
+	}
+	return 1
+}
+
+// hashSkipping hashes the runes that are not skipped with FNV-1a. The deletes
+// are keyed by hash to save memory, the few collisions only add candidates
+// that the edit distance rules out.
+func hashSkipping(runes []rune, skipped []bool) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	var hash uint64 = offset
+	for i, r := range runes {
+		if skipped[i] {
+			continue
+		}
+		for shift := 0; shift < 32; shift += 8 {
+			hash ^= uint64(byte(r >> shift))
+			hash *= prime
+		}
+	}
+
+	return hash
+}
+
+func prefix(word string) []rune {
+	runes := []rune(word)
+	if len(runes) > prefixLength {
+		return runes[:prefixLength]
+	}
+	return runes
+}
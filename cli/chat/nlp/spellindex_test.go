@@ -0,0 +1,117 @@
+package nlp
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/agnivade/levenshtein"
+)
+
+const (
+	testVocabularyFile    = "../../etc/vocabulary.txt"
+	testWordFrequencyFile = "../../etc/word_frequency.txt"
+)
+
+func loadTestIndex(tb testing.TB) *spellIndex {
+	tb.Helper()
+
+	vocabulary, err := loadVocabulary(testVocabularyFile)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	frequencies, err := loadWordFrequency(testWordFrequencyFile)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return newSpellIndex(vocabulary, frequencies, maxEditDistance)
+}
+
+// linearSuggest compares word to every entry, the way suggestions were made
+// before the words were indexed, breaking the ties like suggest.
+func linearSuggest(index *spellIndex, word string) (spellEntry, bool) {
+	best := -1
+	bestDistance := index.maxDistance + 1
+	for id, entry := range index.entries {
+		distance := levenshtein.ComputeDistance(word, entry.key)
+		if distance < bestDistance ||
+			distance == bestDistance && best >= 0 && entry.frequency > index.entries[best].frequency {
+			best = id
+			bestDistance = distance
+		}
+	}
+
+	if best < 0 {
+		return spellEntry{}, false
+	}
+
+	return index.entries[best], true
+}
+
+// misspell makes up to edits random insertions, deletions and substitutions
+// in word.
+func misspell(random *rand.Rand, word string, edits int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	runes := []rune(word)
+	for i := random.Intn(edits + 1); i > 0; i-- {
+		at := random.Intn(len(runes) + 1)
+		letter := rune(letters[random.Intn(len(letters))])
+		switch random.Intn(3) {
+		case 0:
+			runes = append(runes[:at], append([]rune{letter}, runes[at:]...)...)
+		case 1:
+			if at < len(runes) {
+				runes = append(runes[:at], runes[at+1:]...)
+			}
+		default:
+			if at < len(runes) {
+				runes[at] = letter
+			}
+		}
+	}
+
+	return string(runes)
+}
+
+func TestSuggestMatchesLinearScan(t *testing.T) {
+	index := loadTestIndex(t)
+	random := rand.New(rand.NewSource(1))
+
+	queries := []string{"", "a", "periminca", "gatway", "sensr", "conection", "ethrnet", "xqzjvw"}
+	count := 300
+	if testing.Short() {
+		count = 30
+	}
+	for i := 0; i < count; i++ {
+		word := index.entries[random.Intn(len(index.entries))].key
+		queries = append(queries, misspell(random, word, maxEditDistance+1))
+	}
+
+	for _, query := range queries {
+		got, gotOk := index.suggest(query)
+		want, wantOk := linearSuggest(index, query)
+		if gotOk != wantOk || got.key != want.key {
+			t.Errorf("suggest(%q) = %q, %t, the linear scan gives %q, %t", query, got.key, gotOk, want.key, wantOk)
+		}
+	}
+}
+
+func BenchmarkSuggest(b *testing.B) {
+	index := loadTestIndex(b)
+	queries := []string{"periminca", "gatway", "sensr", "conection", "ethrnet", "configuraton"}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		index.suggest(queries[i%len(queries)])
+	}
+}
+
+func BenchmarkLinearSuggest(b *testing.B) {
+	index := loadTestIndex(b)
+	queries := []string{"periminca", "gatway", "sensr", "conection", "ethrnet", "configuraton"}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		linearSuggest(index, queries[i%len(queries)])
+	}
+}
@@ -0,0 +1,119 @@
+package nlp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a piece of a text, either a word to correct or the text around
+// the words, kept as it is.
+type token struct {
+	text string
+	word bool
+}
+
+// tokenize splits text into its words and the text between them, so that
+// joining the tokens gives text back. The words are runs of letters, with
+// the apostrophes between them. Fields with digits, like numbers, units and
+// versions, and fields that look like URLs, e-mails or domains are kept
+// whole as they are.
+func tokenize(text string) []token {
+	var tokens []token
+	for len(text) > 0 {
+		space := unicode.IsSpace(firstRune(text))
+		end := strings.IndexFunc(text, func(r rune) bool {
+			return unicode.IsSpace(r) != space
+		})
+		if end < 0 {
+			end = len(text)
+		}
+		if space {
+			tokens = append(tokens, token{text: text[:end]})
+		} else {
+			tokens = append(tokens, fieldTokens(text[:end])...)
+		}
+		text = text[end:]
+	}
+
+	return tokens
+}
+
+// fieldTokens splits a field, a text without spaces, into its words and
+// punctuation.
+func fieldTokens(field string) []token {
+	if isProtected(field) {
+		return []token{{text: field}}
+	}
+
+	var tokens []token
+	start := 0
+	inWord := false
+	for i, r := range field {
+		letter := unicode.IsLetter(r) || inWord && isApostrophe(r) && nextIsLetter(field[i:])
+		if letter == inWord {
+			continue
+		}
+		if i > start {
+			tokens = append(tokens, token{text: field[start:i], word: inWord})
+		}
+		start = i
+		inWord = letter
+	}
+	if start < len(field) {
+		tokens = append(tokens, token{text: field[start:], word: inWord})
+	}
+
+	return tokens
+}
+
+// isProtected tells whether field must not be corrected: numbers, units,
+// versions, IPs, URLs, e-mails and domains.
+func isProtected(field string) bool {
+	if strings.IndexFunc(field, unicode.IsDigit) >= 0 {
+		return true
+	}
+	if strings.Contains(field, "://") || strings.Contains(field, "@") ||
+		strings.HasPrefix(strings.ToLower(field), "www.") {
+		return true
+	}
+
+	// a dot between letters, like example.com, but not the end of a sentence
+	trimmed := strings.TrimRightFunc(field, unicode.IsPunct)
+	dot := strings.Index(trimmed, ".")
+	return dot > 0 && dot < len(trimmed)-1
+}
+
+// matchCase spells corrected with the case of original: all upper, title or
+// lower. A corrected word with its own mixed case, like a product name from
+// the vocabulary, keeps it.
+func matchCase(original, corrected string) string {
+	if len(corrected) == 0 || corrected != strings.ToLower(corrected) {
+		return corrected
+	}
+
+	switch {
+	case original == strings.ToUpper(original) && utf8.RuneCountInString(original) > 1:
+		return strings.ToUpper(corrected)
+	case unicode.IsUpper(firstRune(original)):
+		first := firstRune(corrected)
+		return string(unicode.ToTitle(first)) + corrected[utf8.RuneLen(first):]
+	default:
+		return corrected
+	}
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// nextIsLetter tells whether the rune after the first one of text is a letter.
+func nextIsLetter(text string) bool {
+	_, size := utf8.DecodeRuneInString(text)
+	return unicode.IsLetter(firstRune(text[size:]))
+}
+
+func firstRune(text string) rune {
+	r, _ := utf8.DecodeRuneInString(text)
+	return r
+}
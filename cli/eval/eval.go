@@ -0,0 +1,12 @@
+// Command eval runs perichat eval, it is kept for the scripts running it.
+package main
+
+import (
+	"os"
+
+	"golangChatBot/perichat"
+)
+
+func main() {
+	perichat.Eval(os.Args[1:])
+}
@@ -0,0 +1,12 @@
+// Command model runs perichat model, it is kept for the scripts running it.
+package main
+
+import (
+	"os"
+
+	"golangChatBot/perichat"
+)
+
+func main() {
+	perichat.Model(os.Args[1:])
+}
@@ -0,0 +1,12 @@
+// Command perichat trains, runs and evaluates the bot, see perichat.Main.
+package main
+
+import (
+	"os"
+
+	"golangChatBot/perichat"
+)
+
+func main() {
+	perichat.Main(os.Args[1:])
+}
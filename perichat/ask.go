@@ -0,0 +1,73 @@
+package perichat
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golangChatBot/bot"
+)
+
+// Ask runs the ask command, answering the questions read from the standard
+// input one by one, without carrying a context between them.
+func Ask(args []string) {
+	flags := flag.NewFlagSet("ask", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	verbose := flags.Bool("v", false, "verbose mode")
+	storeFile := flags.String("c", "corpus.gob", "the file to store corpora")
+	tops := flags.Int("t", 5, "the number of answers to return")
+	flags.Parse(args)
+
+	chatbot, err := NewChatbot(mustLoadConfig(*configFile), *storeFile, *tops, *verbose)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("Q: ")
+		if !scanner.Scan() {
+			fmt.Println()
+			break
+		}
+		question := scanner.Text()
+		if question == "exit" {
+			break
+		}
+
+		startTime := time.Now()
+		corrected, _ := chatbot.Correct(question)
+		answers, err := chatbot.Respond(bot.NewSession("ask", bot.SessionSettings{}), corrected)
+		if err != nil {
+			log.Fatal(err)
+		}
+		latency := time.Since(startTime)
+		if len(answers) == 0 {
+			fmt.Println("No answer!")
+			continue
+		}
+
+		if *tops == 1 {
+			if *verbose {
+				fmt.Printf("A: %s\tConfidence: %.3f\t%s\n", answers[0].Content, answers[0].Confidence, latency)
+			} else {
+				fmt.Printf("A: %s\n", answers[0].Content)
+			}
+			continue
+		}
+
+		for i, answer := range answers {
+			if *verbose {
+				fmt.Printf("%d: %s\tConfidence: %.3f\t%s\n", i+1, answer.Content, answer.Confidence, latency)
+			} else {
+				fmt.Printf("%d: %s\n", i+1, answer.Content)
+			}
+		}
+		if !*verbose {
+			fmt.Println(latency)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package perichat
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"golangChatBot/bot"
+	botnlp "golangChatBot/bot/nlp"
+)
+
+type (
+	// Conversation is the chat saved by /save, as a corpus file.
+	Conversation struct {
+		Categories    []string   `yaml:"categories"`
+		Conversations [][]string `yaml:"conversations"`
+	}
+
+	// chatOptions are the flags of the chat command its helpers need.
+	chatOptions struct {
+		dev  bool
+		anim bool
+	}
+)
+
+// Chat runs the chat command, an interactive chat on the terminal.
+func Chat(args []string) {
+	flags := flag.NewFlagSet("chat", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	dev := flags.Bool("dev", false, "developer mode")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the file to store corpora")
+	tops := flags.Int("t", 1, "the number of answers to return")
+	showIntro := flags.Bool("intro", true, "show the intro message")
+	cpuprofile := flags.String("cpuprofile", "", "write cpu profile to `file`")
+	memprofile := flags.String("memprofile", "", "write memory profile to `file`")
+	httpPort := flags.String("http", "", "start HTTP server on `port` for network profiling")
+	useContext := flags.Bool("context", true, "enable or disable context handling")
+	cmem := flags.Int("cmem", 2, "number of conversations the context remains active (2-4)")
+	anim := flags.Bool("anim", false, "enable or disable animated letter-by-letter printing")
+	flags.Parse(args)
+
+	options := chatOptions{
+		dev:  *dev,
+		anim: *anim,
+	}
+
+	if *cmem < 2 || *cmem > 4 {
+		fmt.Println("Invalid cmem value. Please set it between 2 and 4.")
+		os.Exit(1)
+	}
+
+	if *cpuprofile != "" {
+		f, err := os.Create(*cpuprofile)
+		if err != nil {
+			log.Fatal("could not create CPU profile: ", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			log.Fatal("could not start CPU profile: ", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	if *httpPort != "" {
+		go func() {
+			log.Println("Starting pprof server on port " + *httpPort)
+			log.Println(http.ListenAndServe(":"+*httpPort, nil))
+		}()
+	}
+
+	config := mustLoadConfig(*configFile)
+
+	var chatbot *Chatbot
+	modelLoaded := make(chan bool)
+	go func() {
+		var err error
+		chatbot, err = NewChatbot(config, *storeFile, *tops, *dev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		close(modelLoaded)
+	}()
+	showLoading(modelLoaded)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	var conversationData Conversation
+	session := bot.NewSession("cli", bot.SessionSettings{
+		ContextEnabled: *useContext,
+		ContextMemory:  *cmem,
+	})
+
+	if *showIntro {
+		printIntro(*dev)
+	}
+
+	for {
+		fmt.Print("User: ")
+		if !scanner.Scan() {
+			fmt.Println()
+			if err := scanner.Err(); err != nil {
+				log.Printf("Error reading input: %v", err)
+			}
+			fmt.Println("Exiting chat.")
+			break
+		}
+		question := scanner.Text()
+
+		if *dev {
+			if question == "/exit" {
+				break
+			} else if question == "/save" {
+				options.saveConversation(&conversationData)
+				conversationData = Conversation{}
+				session.Reset()
+				fmt.Println("Conversation saved and data cleared.")
+				continue
+			} else if question == "/explain" {
+				explainLastTurn(session)
+				continue
+			}
+		}
+
+		// WildCard Exit
+		if question == "/geronimo" {
+			break
+		}
+
+		correctedQuestion, corrections := chatbot.Correct(question)
+		if *dev && len(corrections) > 0 {
+			fmt.Printf("Corrected Input: %s\n", correctedQuestion)
+			for _, correction := range corrections {
+				fmt.Printf("  %s\n", correction)
+			}
+		}
+
+		startTime := time.Now()
+
+		options.extractCategoriesForSaving(chatbot.Keywords(), correctedQuestion, &conversationData)
+
+		answers, err := chatbot.Respond(session, correctedQuestion)
+		if err != nil {
+			log.Printf("Error getting response: %v", err)
+			continue
+		}
+
+		if *dev && *useContext {
+			fmt.Printf("Active context: %s\n", strings.Join(session.ActiveCategories(), ", "))
+		}
+
+		var answerContent string
+		if len(answers) == 0 {
+			fmt.Println("PeriChat: " + bot.NoAnswerReply)
+			answerContent = "No answer!"
+		} else {
+			if *tops == 1 {
+				answerContent = answers[0].Content
+				fmt.Print("PeriChat: ")
+				options.typeOutText(answerContent)
+				if *dev {
+					fmt.Printf("\nConfidence: %.3f\tTime: %s", answers[0].Confidence, time.Since(startTime))
+				}
+				fmt.Println()
+			} else {
+				for i, answer := range answers {
+					fmt.Printf("%d: ", i+1)
+					options.typeOutText(answer.Content)
+					if *dev {
+						fmt.Printf("\nConfidence: %.3f\tTime: %s", answer.Confidence, time.Since(startTime))
+					}
+					fmt.Println()
+				}
+				answerContent = answers[0].Content
+			}
+		}
+
+		conversationData.Conversations = append(conversationData.Conversations, []string{correctedQuestion, answerContent})
+
+		options.extractCategoriesForSaving(chatbot.Keywords(), answerContent, &conversationData)
+
+		if *dev {
+			fmt.Println("Time taken:", time.Since(startTime))
+		}
+	}
+
+	if *memprofile != "" {
+		f, err := os.Create(*memprofile)
+		if err != nil {
+			log.Fatal("could not create memory profile: ", err)
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			log.Fatal("could not write memory profile: ", err)
+		}
+	}
+}
+
+func showLoading(modelLoaded chan bool) {
+	spinner := []string{"|", "/", "-", "\\"}
+	i := 0
+	for {
+		select {
+		case <-modelLoaded:
+			fmt.Println("\nModel loaded successfully!")
+			return
+		default:
+			fmt.Printf("\rLoading model... %s", spinner[i])
+			i = (i + 1) % len(spinner)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+}
+
+func (options chatOptions) extractCategoriesForSaving(keywords []string, text string, conversationData *Conversation) {
+	textLower := strings.ToLower(text)
+	if options.dev {
+		fmt.Printf("Analyzing text for saving categories: %s\n", text)
+	}
+	for _, keyword := range keywords {
+		if strings.Contains(textLower, strings.ToLower(keyword)) {
+			if !contains(conversationData.Categories, keyword) {
+				conversationData.Categories = append(conversationData.Categories, keyword)
+				if options.dev {
+					fmt.Printf("Added category '%s' to conversation data.\n", keyword)
+				}
+			}
+		}
+	}
+}
+
+func contains(slice []string, item string) bool {
+	for _, str := range slice {
+		if str == item {
+			return true
+		}
+	}
+	return false
+}
+
+func (options chatOptions) saveConversation(conversationData *Conversation) {
+	if len(conversationData.Conversations) == 0 {
+		fmt.Println("No conversations to save.")
+		return
+	}
+
+	dir := "recent_chats"
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		err = os.Mkdir(dir, 0755)
+		if err != nil {
+			log.Fatalf("Error creating directory %s: %v", dir, err)
+		}
+	}
+	category := "PerinetGenericConversation"
+	if len(conversationData.Categories) > 0 {
+		category = conversationData.Categories[0]
+	}
+
+	timestamp := time.Now().Format("20060102T150405")
+	filename := fmt.Sprintf("perichat%s_%s.yml", timestamp, category)
+	filepath := filepath.Join(dir, filename)
+
+	data, err := yaml.Marshal(&conversationData)
+	if err != nil {
+		log.Fatalf("Error marshaling YAML: %v", err)
+	}
+
+	err = os.WriteFile(filepath, data, 0644)
+	if err != nil {
+		log.Fatalf("Error writing file %s: %v", filepath, err)
+	}
+
+	if options.dev {
+		fmt.Printf("Conversation saved to %s\n", filepath)
+	}
+}
+
+func (options chatOptions) typeOutText(text string) {
+	if options.anim {
+		rand.Seed(time.Now().UnixNano())
+		for _, char := range text {
+			fmt.Printf("%c", char)
+			sleepDuration := time.Duration(rand.Intn(31)+20) * time.Millisecond
+			time.Sleep(sleepDuration)
+		}
+		fmt.Println()
+	} else {
+		fmt.Println(text)
+	}
+}
+
+func explainLastTurn(session *bot.Session) {
+	history := session.History()
+	if len(history) == 0 {
+		fmt.Println("Nothing to explain yet.")
+		return
+	}
+
+	turn := history[len(history)-1]
+	fmt.Printf("Question: %s\n", turn.Question)
+	if len(turn.Answers) == 0 {
+		fmt.Println("No answer was found.")
+		return
+	}
+	for i, answer := range turn.Answers {
+		fmt.Printf("\n%d: %s\n%s", i+1, answer.Content, answer.Explain())
+		if len(answer.Question) > 0 && answer.Question != turn.Question {
+			fmt.Printf("Differences: %s\n", botnlp.DiffWords(turn.Question, answer.Question))
+		}
+	}
+}
+
+func printIntro(devMode bool) {
+	intro := `
+***************************************
+*                                     *
+*        Welcome to PeriChat!         *
+*                                     *
+***************************************
+
+Our purpose is to provide easy answers to your questions about Perinet products, APIs, and any general Perinet-related queries.
+`
+
+	if devMode {
+		intro += "Type '/exit' to end the session, '/save' to save the conversation, '/explain' to see how the last answers were found and '/geronimo' for special exit.\n"
+	} else {
+		intro += "Type '/geronimo' for special exit.\n"
+	}
+
+	fmt.Println(intro)
+}
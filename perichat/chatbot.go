@@ -0,0 +1,225 @@
+package perichat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"golangChatBot/bot"
+	"golangChatBot/bot/adapters/logic"
+	"golangChatBot/bot/adapters/storage"
+	"golangChatBot/cli/chat/nlp"
+)
+
+// Chatbot is the bot the chat, ask, serve and ipc-serve commands answer
+// with, together with its spelling corrector and the sessions of its users.
+type Chatbot struct {
+	bot       *bot.ChatBot
+	store     storage.StorageAdapter
+	corrector *nlp.Corrector
+	sessions  *bot.SessionStore
+	dev       bool
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// NewChatbot builds the bot of config on the model in storeFile: small talk
+// first, then TopicMatch returning at most tops answers, then the fallbacks.
+// In dev mode the adapters are verbose.
+func NewChatbot(config Config, storeFile string, tops int, dev bool) (*Chatbot, error) {
+	return newChatbot(config, storeFile, dev, func(store storage.IndexedStorage) (logic.LogicAdapter, error) {
+		return logic.NewTopicMatchWithConfig(store, tops, config.TopicMatch)
+	})
+}
+
+// newChatbot builds the bot of config on the model in storeFile, answering
+// the questions small talk doesn't with the adapter matcher builds.
+func newChatbot(config Config, storeFile string, dev bool, matcher func(storage.IndexedStorage) (logic.LogicAdapter, error)) (*Chatbot, error) {
+	corrector, err := nlp.NewCorrector(config.correctorConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize NLP: %v", err)
+	}
+
+	keywords, err := bot.LoadKeywords(config.KeywordsFile)
+	if err != nil {
+		log.Printf("Could not load keywords: %v. No keywords will be used for context.", err)
+	}
+	if dev {
+		fmt.Printf("Loaded keywords: %v\n", keywords)
+	}
+
+	store, err := storage.NewSeparatedMemoryStorage(storeFile, config.storageConfig())
+	if err != nil {
+		return nil, err
+	}
+	fallbacks, err := bot.NewFallbacks(config.fallbackConfig())
+	if err != nil {
+		return nil, err
+	}
+	smallTalk, err := logic.NewSmallTalk(config.GreetingsFile, config.SmallTalkFile)
+	if err != nil {
+		return nil, err
+	}
+	match, err := matcher(store)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := &Chatbot{
+		bot: &bot.ChatBot{
+			LogicAdapter:  logic.NewComboMatch(smallTalk, match),
+			Keywords:      keywords,
+			MinConfidence: config.MinConfidence,
+			Fallbacks:     fallbacks,
+		},
+		store:     store,
+		corrector: corrector,
+		sessions:  bot.NewSessionStore(bot.DefaultSessionSettings(), 0),
+		dev:       dev,
+	}
+	if dev {
+		cb.bot.LogicAdapter.SetVerbose()
+	}
+
+	return cb, nil
+}
+
+// Keywords returns the keywords the context categories are detected with.
+func (cb *Chatbot) Keywords() []string {
+	return cb.bot.Keywords
+}
+
+// Correct returns message with its spelling corrected and the corrections.
+func (cb *Chatbot) Correct(message string) (string, []nlp.Correction) {
+	return cb.corrector.Correct(message)
+}
+
+// Respond answers question, already corrected, within session.
+func (cb *Chatbot) Respond(session *bot.Session, question string) ([]logic.Answer, error) {
+	return cb.bot.Respond(context.Background(), session, question)
+}
+
+func (cb *Chatbot) GetResponse(sessionID, message string) string {
+	reply, _ := cb.GetResponseWithAnswers(sessionID, message)
+	return reply
+}
+
+// GetResponseWithAnswers returns the reply together with the answers it was
+// chosen from, which carry where they came from and how they were scored.
+// Without a session id, the message is answered in a session that is not kept.
+func (cb *Chatbot) GetResponseWithAnswers(sessionID, message string) (string, []logic.Answer) {
+	session := cb.sessions.Transient()
+	if sessionID != "" {
+		session = cb.sessions.Get(sessionID)
+	}
+
+	return cb.respondIn(session, message)
+}
+
+// respondIn corrects message and returns the reply within session together
+// with the answers it was chosen from.
+func (cb *Chatbot) respondIn(session *bot.Session, message string) (string, []logic.Answer) {
+	correctedMessage, corrections := cb.corrector.Correct(message)
+	if cb.dev {
+		for _, correction := range corrections {
+			log.Printf("Correction: %s", correction)
+		}
+	}
+
+	answers, err := cb.Respond(session, correctedMessage)
+	if err != nil || len(answers) == 0 {
+		return bot.NoAnswerReply, answers
+	}
+
+	return answers[0].Content, answers
+}
+
+// HandleChat answers the JSON requests of the HTTP API with the reply, and
+// the answers it was chosen from if the request sets debug.
+func (cb *Chatbot) HandleChat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodOptions {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
+		Debug     bool   `json:"debug"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	debug := req.Debug || r.URL.Query().Get("debug") == "true"
+	response, answers := cb.GetResponseWithAnswers(req.SessionID, req.Message)
+
+	resp := struct {
+		Reply     string         `json:"reply"`
+		SessionID string         `json:"session_id,omitempty"`
+		Debug     []logic.Answer `json:"debug,omitempty"`
+	}{
+		Reply:     response,
+		SessionID: req.SessionID,
+	}
+	if debug {
+		resp.Debug = answers
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (cb *Chatbot) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("Upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	// the session of a connection ends with it, so it is not kept
+	session := cb.sessions.Transient()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("Read error: %v", err)
+			}
+			break
+		}
+		clientMessage := string(message)
+		if cb.dev {
+			log.Printf("Received message: %s", clientMessage)
+		}
+
+		response, _ := cb.respondIn(session, clientMessage)
+
+		err = conn.WriteMessage(websocket.TextMessage, []byte(response))
+		if err != nil {
+			log.Println("Write error:", err)
+			break
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package perichat
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"golangChatBot/bot"
+	"golangChatBot/bot/adapters/logic"
+	"golangChatBot/bot/adapters/storage"
+	"golangChatBot/cli/chat/nlp"
+)
+
+// Config is the config file shared by all the commands, each of them reads
+// the settings it needs.
+type Config struct {
+	GreetingsFile          string                 `yaml:"greetings_file"`
+	SmallTalkFile          string                 `yaml:"small_talk_file"`
+	VocabularyFile         string                 `yaml:"vocabulary_file"`
+	KeywordsFile           string                 `yaml:"keywords_file"`
+	CustomDictionaryFile   string                 `yaml:"custom_dictionary_file"`
+	WordFrequencyFile      string                 `yaml:"word_frequency_file"`
+	DictFile               string                 `yaml:"dict_file"`
+	IdfFile                string                 `yaml:"idf_file"`
+	StopWordsFile          string                 `yaml:"stop_words_file"`
+	GeneratedStopWordsFile string                 `yaml:"generated_stop_words_file"`
+	Language               string                 `yaml:"language"`
+	Tokenizer              string                 `yaml:"tokenizer"`
+	ModelGenerations       int                    `yaml:"model_generations"`
+	MinConfidence          float32                `yaml:"min_confidence"`
+	Fallbacks              []string               `yaml:"fallbacks"`
+	ClarificationPrompt    string                 `yaml:"clarification_prompt"`
+	Suggestions            int                    `yaml:"suggestions"`
+	SuggestionConfidence   float32                `yaml:"suggestion_confidence"`
+	UnansweredFile         string                 `yaml:"unanswered_file"`
+	UnansweredReply        string                 `yaml:"unanswered_reply"`
+	TopicMatch             logic.TopicMatchConfig `yaml:"topic_match"`
+}
+
+// LoadConfig reads the config file.
+func LoadConfig(file string) (Config, error) {
+	var config Config
+	configData, err := os.ReadFile(file)
+	if err != nil {
+		return config, fmt.Errorf("error reading config file %s: %v", file, err)
+	}
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		return config, fmt.Errorf("error parsing config file %s: %v", file, err)
+	}
+
+	return config, nil
+}
+
+// mustLoadConfig reads the config file of a command, exiting on errors.
+func mustLoadConfig(file string) Config {
+	config, err := LoadConfig(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return config
+}
+
+func (config Config) storageConfig() storage.Config {
+	return storage.Config{
+		DictFile:               config.DictFile,
+		IdfFile:                config.IdfFile,
+		StopWordsFile:          config.StopWordsFile,
+		GeneratedStopWordsFile: config.GeneratedStopWordsFile,
+		Language:               config.Language,
+		Tokenizer:              config.Tokenizer,
+		Generations:            config.ModelGenerations,
+	}
+}
+
+func (config Config) fallbackConfig() bot.FallbackConfig {
+	return bot.FallbackConfig{
+		Chain:                config.Fallbacks,
+		ClarificationPrompt:  config.ClarificationPrompt,
+		Suggestions:          config.Suggestions,
+		SuggestionConfidence: config.SuggestionConfidence,
+		UnansweredFile:       config.UnansweredFile,
+		UnansweredReply:      config.UnansweredReply,
+	}
+}
+
+func (config Config) correctorConfig() nlp.Config {
+	return nlp.Config{
+		CustomDictionaryFile: config.CustomDictionaryFile,
+		VocabularyFile:       config.VocabularyFile,
+		WordFrequencyFile:    config.WordFrequencyFile,
+	}
+}
@@ -0,0 +1,459 @@
+package perichat
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"golangChatBot/bot"
+	"golangChatBot/bot/adapters/logic"
+	"golangChatBot/bot/adapters/storage"
+)
+
+const uncategorized = "uncategorized"
+
+type (
+	// Case is one entry of a test set. Answer is the expected answer, Source
+	// the stored question whose answers count as correct, at least one is set.
+	Case struct {
+		Question string `yaml:"question" json:"question"`
+		Answer   string `yaml:"answer" json:"answer"`
+		Source   string `yaml:"source" json:"source"`
+		Category string `yaml:"category" json:"category"`
+	}
+
+	Metrics struct {
+		Cases     int     `json:"cases"`
+		Top1      float64 `json:"top1"`
+		RecallAtK float64 `json:"recall_at_k"`
+		MRR       float64 `json:"mrr"`
+	}
+
+	Latency struct {
+		P50 time.Duration `json:"p50"`
+		P90 time.Duration `json:"p90"`
+		P99 time.Duration `json:"p99"`
+		Max time.Duration `json:"max"`
+	}
+
+	Miss struct {
+		Question string `json:"question"`
+		Expected string `json:"expected"`
+		Got      string `json:"got"`
+	}
+
+	Report struct {
+		Adapter string `json:"adapter"`
+		K       int    `json:"k"`
+		Metrics
+		Latency    Latency            `json:"latency"`
+		Categories map[string]Metrics `json:"categories"`
+		Misses     []Miss             `json:"misses,omitempty"`
+	}
+)
+
+// Eval runs the eval command, measuring how well the chatbot answers the
+// questions of a test set with a logic adapter. The questions go the way of
+// the other commands: spelling correction, small talk, the adapter, the
+// minimum confidence and the fallbacks.
+func Eval(args []string) {
+	flags := flag.NewFlagSet("eval", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the file to load corpora from")
+	testSet := flags.String("i", "", "the test set, .yml/.yaml/.json list or .jsonl with one case per line")
+	adapter := flags.String("adapter", "topic", "the logic adapter to evaluate: topic, closest, bm25, combo or first")
+	weights := flags.String("weights", "topic=1,closest=1", "the weights of the adapters merged by combo")
+	threshold := flags.Float64("threshold", 0.5, "the confidence that stops first at an adapter")
+	k := flags.Int("k", 5, "the number of answers to consider for recall and MRR")
+	jsonOutput := flags.Bool("json", false, "print the report as JSON")
+	baseline := flags.String("baseline", "", "a JSON report to compare against, exits with 1 on regression")
+	tolerance := flags.Float64("tolerance", 0.005, "the allowed drop of a metric against the baseline")
+	latencyTolerance := flags.Float64("latency-tolerance", 0.5, "the allowed relative rise of the latency percentiles against the baseline")
+	verbose := flags.Bool("v", false, "list the questions that were not answered correctly")
+	flags.Parse(args)
+
+	if len(*testSet) == 0 || *k < 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config := mustLoadConfig(*configFile)
+
+	cases, err := loadCases(*testSet)
+	if err != nil {
+		log.Fatalf("Error loading test set %s: %v", *testSet, err)
+	}
+
+	// the questions of the test set are not questions users left unanswered
+	config.UnansweredFile = ""
+	chatbot, err := newChatbot(config, *storeFile, false, func(store storage.IndexedStorage) (logic.LogicAdapter, error) {
+		return evalAdapter(store, config, *adapter, *weights, float32(*threshold), *k)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report := evaluate(chatbot, cases, *adapter, *k, *verbose)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printReport(report)
+	}
+
+	if len(*baseline) > 0 {
+		regressions, err := compareBaseline(report, *baseline, *tolerance, *latencyTolerance)
+		if err != nil {
+			log.Fatalf("Error comparing against baseline %s: %v", *baseline, err)
+		}
+		if len(regressions) > 0 {
+			for _, regression := range regressions {
+				fmt.Fprintln(os.Stderr, "regression:", regression)
+			}
+			os.Exit(1)
+		}
+	}
+}
+
+// evalAdapter builds the adapter named by name on store.
+func evalAdapter(store storage.IndexedStorage, config Config, name, weights string, threshold float32, k int) (logic.LogicAdapter, error) {
+	topicMatch, err := logic.NewTopicMatchWithConfig(store, k, config.TopicMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "topic":
+		return topicMatch, nil
+	case "closest":
+		return logic.NewClosestMatch(store, k), nil
+	case "bm25":
+		return logic.NewBM25Match(store, k), nil
+	case "combo":
+		adapters, err := weightedAdapters(map[string]logic.LogicAdapter{
+			"topic":   topicMatch,
+			"closest": logic.NewClosestMatch(store, k),
+			"bm25":    logic.NewBM25Match(store, k),
+		}, weights)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing weights %q: %w", weights, err)
+		}
+		return logic.NewMergingComboMatch(k, adapters...), nil
+	case "first":
+		return logic.NewFirstConfidentComboMatch(threshold, topicMatch, logic.NewClosestMatch(store, k)), nil
+	default:
+		return nil, fmt.Errorf("unknown adapter %q, use topic, closest, bm25, combo or first", name)
+	}
+}
+
+// weightedAdapters parses a list like topic=0.7,closest=0.3.
+func weightedAdapters(adapters map[string]logic.LogicAdapter, weights string) ([]logic.WeightedAdapter, error) {
+	var result []logic.WeightedAdapter
+	for _, each := range strings.Split(weights, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(each), "=")
+		if !ok {
+			return nil, fmt.Errorf("expected name=weight, got %q", each)
+		}
+
+		weight, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		logicAdapter, ok := adapters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown adapter %q", name)
+		}
+		result = append(result, logic.WeightedAdapter{
+			Adapter: logicAdapter,
+			Weight:  float32(weight),
+		})
+	}
+
+	return result, nil
+}
+
+func loadCases(file string) ([]Case, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".jsonl":
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if len(text) == 0 {
+				continue
+			}
+
+			var each Case
+			if err := json.Unmarshal([]byte(text), &each); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			cases = append(cases, each)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case ".json":
+		if err := json.Unmarshal(content, &cases); err != nil {
+			return nil, err
+		}
+	case ".yml", ".yaml":
+		if err := yaml.Unmarshal(content, &cases); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown file type: %s", ext)
+	}
+
+	for i, each := range cases {
+		if len(each.Question) == 0 || (len(each.Answer) == 0 && len(each.Source) == 0) {
+			return nil, fmt.Errorf("case %d needs a question and an answer or source", i+1)
+		}
+	}
+
+	return cases, nil
+}
+
+// evaluate runs the cases through chatbot, the first k answers of a case
+// count. With verbose, the report lists the cases missed.
+func evaluate(chatbot *Chatbot, cases []Case, adapter string, k int, verbose bool) Report {
+	var (
+		total     stats
+		latencies []time.Duration
+		misses    []Miss
+	)
+	categories := make(map[string]*stats)
+	settings := bot.DefaultSessionSettings()
+	settings.Tops = k
+
+	for _, each := range cases {
+		// every case is a new conversation, the context of the others
+		// doesn't carry over
+		session := bot.NewSession("", settings)
+		start := time.Now()
+		_, answers := chatbot.respondIn(session, each.Question)
+		latencies = append(latencies, time.Since(start))
+
+		rank := rankOf(chatbot.store, each, answers)
+
+		category := each.Category
+		if len(category) == 0 {
+			category = uncategorized
+		}
+		if _, ok := categories[category]; !ok {
+			categories[category] = new(stats)
+		}
+		total.add(rank)
+		categories[category].add(rank)
+
+		if rank != 1 && verbose {
+			miss := Miss{
+				Question: each.Question,
+				Expected: each.Answer,
+			}
+			if len(miss.Expected) == 0 {
+				miss.Expected = each.Source
+			}
+			if len(answers) > 0 {
+				miss.Got = answers[0].Content
+			}
+			misses = append(misses, miss)
+		}
+	}
+
+	report := Report{
+		Adapter:    adapter,
+		K:          k,
+		Metrics:    total.metrics(),
+		Latency:    percentiles(latencies),
+		Categories: make(map[string]Metrics, len(categories)),
+		Misses:     misses,
+	}
+	for category, each := range categories {
+		report.Categories[category] = each.metrics()
+	}
+
+	return report
+}
+
+// rankOf returns the 1-based position of the first correct answer, 0 if none.
+func rankOf(store storage.StorageAdapter, each Case, answers []logic.Answer) int {
+	var accepted map[string]int
+	if len(each.Source) > 0 {
+		accepted, _ = store.Find(each.Source)
+	}
+
+	for i, answer := range answers {
+		if len(each.Answer) > 0 && normalize(answer.Content) == normalize(each.Answer) {
+			return i + 1
+		}
+		if _, ok := accepted[answer.Content]; ok {
+			return i + 1
+		}
+	}
+
+	return 0
+}
+
+func normalize(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+type stats struct {
+	cases      int
+	top1       int
+	recalled   int
+	reciprocal float64
+}
+
+func (s *stats) add(rank int) {
+	s.cases++
+	if rank == 1 {
+		s.top1++
+	}
+	if rank > 0 {
+		s.recalled++
+		s.reciprocal += 1 / float64(rank)
+	}
+}
+
+func (s *stats) metrics() Metrics {
+	if s.cases == 0 {
+		return Metrics{}
+	}
+
+	return Metrics{
+		Cases:     s.cases,
+		Top1:      float64(s.top1) / float64(s.cases),
+		RecallAtK: float64(s.recalled) / float64(s.cases),
+		MRR:       s.reciprocal / float64(s.cases),
+	}
+}
+
+func percentiles(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	at := func(p float64) time.Duration {
+		index := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		return sorted[index]
+	}
+
+	return Latency{
+		P50: at(0.5),
+		P90: at(0.9),
+		P99: at(0.99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+// compareBaseline returns the regressions of report against the JSON report
+// in file: the metrics, overall and of the categories of both, dropping by
+// more than tolerance, and the latency percentiles rising by more than
+// latencyTolerance times their baseline.
+func compareBaseline(report Report, file string, tolerance, latencyTolerance float64) ([]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var base Report
+	if err := json.Unmarshal(content, &base); err != nil {
+		return nil, err
+	}
+
+	var regressions []string
+	checkMetrics := func(scope string, current, previous Metrics) {
+		check := func(name string, current, previous float64) {
+			if current < previous-tolerance {
+				regressions = append(regressions, fmt.Sprintf("%s%s dropped from %.4f to %.4f", scope, name, previous, current))
+			}
+		}
+		check("top1", current.Top1, previous.Top1)
+		check(fmt.Sprintf("recall@%d", report.K), current.RecallAtK, previous.RecallAtK)
+		check("mrr", current.MRR, previous.MRR)
+	}
+	checkMetrics("", report.Metrics, base.Metrics)
+
+	names := make([]string, 0, len(base.Categories))
+	for name := range base.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// categories added to or dropped from the test set have nothing to compare
+		if current, ok := report.Categories[name]; ok {
+			checkMetrics(name+" ", current, base.Categories[name])
+		}
+	}
+
+	checkLatency := func(name string, current, previous time.Duration) {
+		if previous > 0 && float64(current) > float64(previous)*(1+latencyTolerance) {
+			regressions = append(regressions, fmt.Sprintf("latency %s rose from %s to %s", name, previous, current))
+		}
+	}
+	checkLatency("p50", report.Latency.P50, base.Latency.P50)
+	checkLatency("p90", report.Latency.P90, base.Latency.P90)
+	checkLatency("p99", report.Latency.P99, base.Latency.P99)
+
+	return regressions, nil
+}
+
+func printReport(report Report) {
+	fmt.Printf("Adapter: %s\tCases: %d\n", report.Adapter, report.Cases)
+	fmt.Printf("Top-1 accuracy: %.4f\n", report.Top1)
+	fmt.Printf("Recall@%d: %.4f\n", report.K, report.RecallAtK)
+	fmt.Printf("MRR: %.4f\n", report.MRR)
+	fmt.Printf("Latency p50: %s\tp90: %s\tp99: %s\tmax: %s\n",
+		report.Latency.P50, report.Latency.P90, report.Latency.P99, report.Latency.Max)
+
+	names := make([]string, 0, len(report.Categories))
+	for name := range report.Categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nPer category:")
+	for _, name := range names {
+		each := report.Categories[name]
+		fmt.Printf("\t%s\tcases: %d\ttop1: %.4f\trecall@%d: %.4f\tmrr: %.4f\n",
+			name, each.Cases, each.Top1, report.K, each.RecallAtK, each.MRR)
+	}
+
+	if len(report.Misses) > 0 {
+		fmt.Println("\nMisses:")
+		for _, miss := range report.Misses {
+			fmt.Printf("\tQ: %s\n\t\texpected: %s\n\t\tgot: %s\n", miss.Question, miss.Expected, miss.Got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package perichat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareBaseline(t *testing.T) {
+	base := Report{
+		K:       5,
+		Metrics: Metrics{Cases: 4, Top1: 0.75, RecallAtK: 1, MRR: 0.8},
+		Latency: Latency{P50: time.Millisecond, P90: 2 * time.Millisecond, P99: 4 * time.Millisecond},
+		Categories: map[string]Metrics{
+			"periMICA": {Cases: 2, Top1: 1, RecallAtK: 1, MRR: 1},
+			"periNODE": {Cases: 2, Top1: 0.5, RecallAtK: 1, MRR: 0.6},
+			"dropped":  {Cases: 1, Top1: 1, RecallAtK: 1, MRR: 1},
+		},
+	}
+	content, err := json.Marshal(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		change      func(report *Report)
+		regressions []string
+	}{
+		{"unchanged", func(report *Report) {}, nil},
+		{"overall", func(report *Report) { report.Top1 = 0.5 }, []string{"top1 dropped"}},
+		{"category", func(report *Report) {
+			report.Categories["periNODE"] = Metrics{Cases: 2, Top1: 0.5, RecallAtK: 0.5, MRR: 0.5}
+		}, []string{"periNODE recall@5 dropped", "periNODE mrr dropped"}},
+		{"new category", func(report *Report) {
+			report.Categories["greetings"] = Metrics{Cases: 1}
+		}, nil},
+		{"latency", func(report *Report) {
+			report.Latency.P50 = 1400 * time.Microsecond
+			report.Latency.P90 = 4 * time.Millisecond
+		}, []string{"latency p90 rose"}},
+	}
+
+	for _, test := range tests {
+		report := base
+		report.Categories = map[string]Metrics{
+			"periMICA": base.Categories["periMICA"],
+			"periNODE": base.Categories["periNODE"],
+		}
+		test.change(&report)
+
+		regressions, err := compareBaseline(report, file, 0.005, 0.5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(regressions) != len(test.regressions) {
+			t.Errorf("%s: got the regressions %q, want %q", test.name, regressions, test.regressions)
+			continue
+		}
+		for i, regression := range regressions {
+			if !strings.HasPrefix(regression, test.regressions[i]) {
+				t.Errorf("%s: got the regression %q, want %q", test.name, regression, test.regressions[i])
+			}
+		}
+	}
+}
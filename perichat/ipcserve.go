@@ -0,0 +1,112 @@
+package perichat
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+
+	"golangChatBot/IPC/ipc"
+	"golangChatBot/bot/adapters/logic"
+)
+
+// IPCMessage is a line of the IPC protocol, a request of the web server or
+// the reply of the bot.
+type IPCMessage struct {
+	RequestID string `json:"request_id"`
+	SessionID string `json:"session_id,omitempty"`
+	Message   string `json:"message"`
+	Reply     string `json:"reply,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Debug asks for the answers the reply was chosen from.
+	Debug   bool           `json:"debug,omitempty"`
+	Answers []logic.Answer `json:"answers,omitempty"`
+}
+
+// IPCServe runs the ipc-serve command, the bot answering the messages of
+// the web server of IPC/web over a named pipe or a unix socket.
+func IPCServe(args []string) {
+	flags := flag.NewFlagSet("ipc-serve", flag.ExitOnError)
+	ipcPipeName := flags.String("ipc_pipe", `\\.\pipe\chatbot_pipe`, "Named pipe for IPC communication (Windows) or socket path for Linux")
+	configFile := flags.String("config", "./config_local_gen.yaml", "Path to the config file")
+	devMode := flags.Bool("dev", false, "Developer mode")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "File to store corpora")
+	tops := flags.Int("t", 1, "Number of answers to return")
+	flags.Parse(args)
+
+	log.Printf("Initializing Chatbot Service...")
+
+	chatbot, err := NewChatbot(mustLoadConfig(*configFile), *storeFile, *tops, *devMode)
+	if err != nil {
+		log.Fatalf("Error initializing chatbot: %v", err)
+	}
+
+	ipcInstance := ipc.NewIPC(*ipcPipeName)
+
+	conn, err := ipcInstance.Listen()
+	if err != nil {
+		log.Fatalf("Failed to listen on IPC: %v", err)
+	}
+	defer conn.Close()
+	log.Printf("Chatbot Service listening on %s...", *ipcPipeName)
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Error reading from IPC: %v", err)
+			}
+			break
+		}
+
+		var msg IPCMessage
+		err = json.Unmarshal(line, &msg)
+		if err != nil {
+			log.Printf("Invalid JSON from IPC: %v", err)
+			sendError(writer, msg.RequestID, "Invalid JSON format")
+			continue
+		}
+
+		log.Printf("Received message: %+v", msg)
+
+		reply, answers := chatbot.GetResponseWithAnswers(msg.SessionID, msg.Message)
+		log.Printf("Generated reply: %s", reply)
+
+		resp := IPCMessage{
+			RequestID: msg.RequestID,
+			SessionID: msg.SessionID,
+			Reply:     reply,
+		}
+		if msg.Debug {
+			resp.Answers = answers
+		}
+
+		sendMessage(writer, resp)
+	}
+}
+
+func sendMessage(writer *bufio.Writer, msg IPCMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to marshal message: %v", err)
+		return
+	}
+	_, err = writer.Write(append(data, '\n'))
+	if err != nil {
+		log.Printf("Failed to send message: %v", err)
+		return
+	}
+	writer.Flush()
+}
+
+func sendError(writer *bufio.Writer, requestID, errorMsg string) {
+	resp := IPCMessage{
+		RequestID: requestID,
+		Error:     errorMsg,
+	}
+	sendMessage(writer, resp)
+}
@@ -0,0 +1,164 @@
+package perichat
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"golangChatBot/bot/adapters/storage"
+)
+
+const modelUsage = `Usage: perichat model <command> [flags]
+
+Commands:
+	bench	time loading a model file, the first load and the following ones
+	info	print the header of a model file
+	migrate	rewrite a model file in the current format
+	rollback	replace a model file with one of its previous generations
+`
+
+// Model runs the model command, the tools to inspect and maintain model files.
+func Model(args []string) {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, modelUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "bench":
+		bench(args[1:])
+	case "info":
+		info(args[1:])
+	case "migrate":
+		migrate(args[1:])
+	case "rollback":
+		rollback(args[1:])
+	default:
+		fmt.Fprint(os.Stderr, modelUsage)
+		os.Exit(2)
+	}
+}
+
+func bench(args []string) {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the model file")
+	loads := flags.Int("n", 5, "the number of times to load the model")
+	flags.Parse(args)
+
+	config := mustLoadConfig(*configFile).storageConfig()
+	// the first load pays for the NLP resources, the next ones share them,
+	// like the storages of a chat, web or IPC process do.
+	var first, rest time.Duration
+	var stores []storage.StorageAdapter
+	for i := 0; i < *loads; i++ {
+		start := time.Now()
+		store, err := storage.NewSeparatedMemoryStorage(*storeFile, config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		elapsed := time.Since(start)
+		if i == 0 {
+			first = elapsed
+		} else {
+			rest += elapsed
+		}
+		stores = append(stores, store)
+	}
+
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	fmt.Printf("File: %s\n", *storeFile)
+	fmt.Printf("First load: %v\n", first)
+	if *loads > 1 {
+		fmt.Printf("Next loads: %v on average\n", rest/time.Duration(*loads-1))
+	}
+	fmt.Printf("Heap in use with %d loaded models: %.1f MB\n", len(stores), float64(stats.HeapInuse)/(1<<20))
+	runtime.KeepAlive(stores)
+}
+
+func info(args []string) {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the model file")
+	flags.Parse(args)
+
+	header, err := storage.ReadModelHeader(*storeFile)
+	if err != nil {
+		log.Fatalf("Error reading model file %s: %v", *storeFile, err)
+	}
+
+	fmt.Printf("File: %s\n", *storeFile)
+	fmt.Printf("Format version: %d", header.Version)
+	if header.Version < storage.ModelFormatVersion {
+		fmt.Printf(" (legacy, run migrate to upgrade to %d)", storage.ModelFormatVersion)
+	}
+	fmt.Println()
+	if header.Version < storage.ModelFormatVersion {
+		fmt.Printf("Payload size: %d bytes\n", header.PayloadSize)
+		return
+	}
+
+	fmt.Printf("Created at: %s\n", header.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("Keys: %d\n", header.Keys)
+	fmt.Printf("Payload size: %d bytes\n", header.PayloadSize)
+	fmt.Printf("Checksum: sha256:%s\n", header.Checksum)
+	fmt.Println("Tokenizer:")
+	if len(header.Tokenizer.Name) > 0 {
+		fmt.Printf("\tName: %s\n", header.Tokenizer.Name)
+	}
+	fmt.Printf("\tLanguage: %s\n", header.Tokenizer.Language)
+	fmt.Printf("\tDict file: %s\n", header.Tokenizer.DictFile)
+	fmt.Printf("\tIdf file: %s\n", header.Tokenizer.IdfFile)
+	fmt.Printf("\tStop words file: %s\n", header.Tokenizer.StopWordsFile)
+
+	hashes := make([]string, 0, len(header.Corpora))
+	for hash := range header.Corpora {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return header.Corpora[hashes[i]] < header.Corpora[hashes[j]]
+	})
+
+	fmt.Printf("Corpora: %d\n", len(hashes))
+	for _, hash := range hashes {
+		fmt.Printf("\t%s\t%s\n", hash[:12], header.Corpora[hash])
+	}
+}
+
+func migrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the model file")
+	flags.Parse(args)
+
+	store, err := storage.NewSeparatedMemoryStorage(*storeFile, mustLoadConfig(*configFile).storageConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := store.Sync(); err != nil {
+		log.Fatalf("Error writing model file %s: %v", *storeFile, err)
+	}
+
+	fmt.Printf("Migrated %s to format version %d\n", *storeFile, storage.ModelFormatVersion)
+}
+
+func rollback(args []string) {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the model file")
+	generation := flags.Int("n", 1, "the generation to restore, 1 is the most recent")
+	flags.Parse(args)
+
+	if err := storage.RollbackModel(*storeFile, *generation); err != nil {
+		log.Fatalf("Error rolling back model file %s: %v", *storeFile, err)
+	}
+
+	fmt.Printf("Restored %s from %s, the replaced model is %s\n", *storeFile,
+		storage.GenerationPath(*storeFile, *generation), storage.GenerationPath(*storeFile, 1))
+}
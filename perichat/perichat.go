@@ -0,0 +1,49 @@
+// Package perichat holds the commands of the perichat binary. They share
+// the config file and build the bot the same way, the main packages of cli,
+// web, IPC/Chatbot and bin only run one of them.
+package perichat
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `Usage: perichat <command> [flags]
+
+Commands:
+	train	train corpora files into a model file
+	chat	chat with the bot on the terminal
+	ask	answer questions from the standard input, without context
+	serve	serve the HTTP API, the web page and the WebSocket endpoint
+	ipc-serve	answer the web server of IPC/web over a named pipe or socket
+	eval	evaluate a logic adapter on a test set
+	model	inspect and maintain model files
+
+Run perichat <command> -h for the flags of a command.
+`
+
+var commands = map[string]func([]string){
+	"train":     Train,
+	"chat":      Chat,
+	"ask":       Ask,
+	"serve":     Serve,
+	"ipc-serve": IPCServe,
+	"eval":      Eval,
+	"model":     Model,
+}
+
+// Main runs the command named by the first of args with the rest of them.
+func Main(args []string) {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command(args[1:])
+}
@@ -0,0 +1,50 @@
+package perichat
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Serve runs the serve command, the HTTP API of the bot on /chat and
+// /get_response, the web page in the static directory and, if enabled, the
+// WebSocket endpoint on /ws.
+func Serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configFile := flags.String("config", "./config_local_gen.yaml", "path to the config file")
+	dev := flags.Bool("dev", false, "developer mode")
+	storeFile := flags.String("c", "PMFuncOverView.gob", "the file to store corpora")
+	tops := flags.Int("t", 1, "the number of answers to return")
+	enableWs := flags.Bool("enableWs", false, "enable WebSocket endpoint")
+	listen := flags.String("listen", ":8080", "the address to listen on")
+	static := flags.String("static", "./static", "the directory of the web page, empty to serve none")
+	flags.Parse(args)
+
+	chatbot, err := NewChatbot(mustLoadConfig(*configFile), *storeFile, *tops, *dev)
+	if err != nil {
+		log.Fatalf("Error initializing chatbot: %v", err)
+	}
+
+	// not the default mux, the profiling handlers of chat are registered there
+	mux := http.NewServeMux()
+	if len(*static) > 0 {
+		mux.Handle("/", http.FileServer(http.Dir(*static)))
+	}
+
+	if *enableWs {
+		mux.HandleFunc("/ws", chatbot.HandleWebSocket)
+		fmt.Println("WebSocket endpoint /ws is enabled")
+	} else {
+		fmt.Println("WebSocket endpoint /ws is disabled")
+	}
+
+	mux.HandleFunc("/chat", chatbot.HandleChat)
+	// the endpoint of the chatbot service bin/web forwards to
+	mux.HandleFunc("/get_response", chatbot.HandleChat)
+
+	fmt.Printf("Starting server on %s...\n", *listen)
+	if err := http.ListenAndServe(*listen, mux); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
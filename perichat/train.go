@@ -0,0 +1,301 @@
+package perichat
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golangChatBot/bot"
+	"golangChatBot/bot/adapters/storage"
+	"golangChatBot/bot/corpus"
+)
+
+func preprocessYAMLFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	inConversations := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "conversations:") {
+			inConversations = true
+			continue
+		}
+		if inConversations {
+			if strings.HasPrefix(trimmed, "-") {
+				index := strings.Index(line, "- ")
+				if index >= 0 {
+					content := line[index+2:]
+					content = strings.TrimSpace(content)
+					if strings.HasPrefix(content, "- ") {
+						continue
+					}
+					if !strings.HasPrefix(content, "\"") && strings.Contains(content, ":") {
+						content = "\"" + content + "\""
+						line = line[:index+2] + content
+						lines[i] = line
+					}
+				}
+			} else if len(trimmed) == 0 {
+				inConversations = false
+			}
+		}
+	}
+
+	newData := strings.Join(lines, "\n")
+	return newData, nil
+}
+
+// Train runs the train command, training the corpora files into a model file.
+func Train(args []string) {
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	configFile := flags.String("config", "/app/cli/config.yaml", "path to the config file")
+	dir := flags.String("d", "", "the directory to look for corpora files")
+	corpora := flags.String("i", "", "the corpora files, comma to separate multiple files")
+	storeFile := flags.String("o", "corpus.gob", "the file to store corpora")
+	printMemStats := flags.Bool("m", false, "enable printing memory stats")
+	logFile := flags.String("log", "train.log", "the file to write logs to")
+	extensions := flags.String("ext", "json,yml,yaml", "file extensions to look for, separated by commas")
+	incremental := flags.Bool("incremental", false, "only train corpora files not yet in the store file and index just the new questions, all of them are trained again if one changed")
+	flags.Parse(args)
+
+	config := mustLoadConfig(*configFile)
+
+	f, err := os.OpenFile(*logFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		fmt.Printf("Error opening log file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	multiWriter := io.MultiWriter(os.Stdout, f)
+	log.SetOutput(multiWriter)
+	exts := strings.Split(*extensions, ",")
+	for i, ext := range exts {
+		exts[i] = strings.TrimPrefix(ext, ".")
+		exts[i] = strings.ToLower(exts[i])
+	}
+
+	log.Printf("Config File: %s", *configFile)
+	log.Printf("Directory: %s", *dir)
+	log.Printf("Corpora: %s", *corpora)
+	log.Printf("Store File: %s", *storeFile)
+	log.Printf("Print Memory Stats: %v", *printMemStats)
+	log.Printf("Log File: %s", *logFile)
+	log.Printf("Extensions: %v", exts)
+	log.Printf("Incremental: %v", *incremental)
+
+	var corporaFiles []string
+	if len(*dir) > 0 {
+		files := findCorporaFiles(*dir, exts)
+		corporaFiles = append(corporaFiles, files...)
+	}
+
+	if len(*corpora) > 0 {
+		corporaFiles = append(corporaFiles, strings.Split(*corpora, ",")...)
+	}
+
+	if len(corporaFiles) == 0 {
+		flags.Usage()
+		return
+	}
+
+	if err := trainCorpora(config, *storeFile, corporaFiles, *incremental, *printMemStats); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// trainCorpora trains files into the model in storeFile. A full training
+// starts from scratch, an incremental one adds the files the model was not
+// trained with to it, unless one it was trained with has changed since:
+// its old conversations can't be taken out of the counts, so all of them
+// are trained from scratch again.
+func trainCorpora(config Config, storeFile string, files []string, incremental, printMemStats bool) error {
+	open := storage.NewEmptySeparatedMemoryStorage
+	if incremental {
+		open = storage.NewSeparatedMemoryStorage
+	}
+	store, err := open(storeFile, config.storageConfig())
+	if err != nil {
+		return err
+	}
+
+	if incremental {
+		retrain, err := corporaToRetrain(store, files)
+		if err != nil {
+			return err
+		}
+		if len(retrain) > 0 {
+			log.Printf("Corpora files changed since %s was trained, training all of them again.", storeFile)
+			if store, err = storage.NewEmptySeparatedMemoryStorage(storeFile, config.storageConfig()); err != nil {
+				return err
+			}
+			files = retrain
+			incremental = false
+		}
+	}
+
+	files, err = registerCorpora(store, files, incremental)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Printf("All corpora files are already in %s, nothing to train.", storeFile)
+		return nil
+	}
+
+	log.Printf("Training on corpora files: %v", files)
+
+	trainer := bot.NewCorpusTrainer(store)
+	if incremental {
+		trainer = bot.NewIncrementalCorpusTrainer(store)
+	}
+
+	chatbot := &bot.ChatBot{
+		PrintMemStats:  printMemStats,
+		Trainer:        trainer,
+		StorageAdapter: store,
+	}
+
+	dir, err := os.MkdirTemp("", "corpora")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	processedFiles := []string{}
+	for i, filename := range files {
+		newData, err := preprocessYAMLFile(filename)
+		if err != nil {
+			return fmt.Errorf("error preprocessing file %s: %w", filename, err)
+		}
+
+		processed := filepath.Join(dir, fmt.Sprintf("corpus_%d.yaml", i))
+		if err := os.WriteFile(processed, []byte(newData), 0o644); err != nil {
+			return fmt.Errorf("error writing to temporary file %s: %w", processed, err)
+		}
+		processedFiles = append(processedFiles, processed)
+	}
+
+	startTime := time.Now()
+	if err := chatbot.Train(processedFiles); err != nil {
+		return err
+	}
+
+	elapsedTime := time.Since(startTime)
+	log.Printf("Training completed successfully in %s.", elapsedTime)
+
+	return nil
+}
+
+// corporaToRetrain returns the files to train from scratch if a file store was
+// trained with has changed since, nil if none has. Those are files and the
+// other files store was trained with, which must still be there.
+func corporaToRetrain(store storage.CorpusRegistry, files []string) ([]string, error) {
+	trained := make(map[string]string)
+	for hash, file := range store.Corpora() {
+		trained[filepath.Clean(file)] = hash
+	}
+
+	changed := false
+	given := make(map[string]bool, len(files))
+	for _, file := range files {
+		given[filepath.Clean(file)] = true
+		hash, ok := trained[filepath.Clean(file)]
+		if !ok {
+			continue
+		}
+
+		current, err := corpus.Hash(file)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing file %s: %w", file, err)
+		}
+		if current != hash {
+			log.Printf("%s changed since it was trained.", file)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	result := append([]string(nil), files...)
+	others := make([]string, 0, len(trained))
+	for file := range trained {
+		if !given[file] {
+			others = append(others, file)
+		}
+	}
+	sort.Strings(others)
+	for _, file := range others {
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("%s, which the model was trained with, is needed to train it again: %w", file, err)
+		}
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+// registerCorpora records the content hashes of files in store and returns the
+// files to train. In incremental mode the files already in store are skipped.
+func registerCorpora(store storage.CorpusRegistry, files []string, incremental bool) ([]string, error) {
+	var result []string
+	for _, file := range files {
+		hash, err := corpus.Hash(file)
+		if err != nil {
+			return nil, fmt.Errorf("error hashing file %s: %w", file, err)
+		}
+
+		if store.HasCorpus(hash) {
+			if incremental {
+				log.Printf("Skipping %s, already trained.", file)
+			} else {
+				log.Printf("Skipping %s, the same as another file.", file)
+			}
+			continue
+		}
+
+		store.AddCorpus(hash, file)
+		result = append(result, file)
+	}
+
+	return result, nil
+}
+
+func findCorporaFiles(dir string, extensions []string) []string {
+	var files []string
+	extMap := make(map[string]bool)
+	for _, ext := range extensions {
+		extMap["."+strings.ToLower(ext)] = true
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			log.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if !info.IsDir() {
+			ext := strings.ToLower(filepath.Ext(info.Name()))
+			if extMap[ext] {
+				files = append(files, path)
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("Error walking the path %s: %v", dir, err)
+	}
+
+	log.Printf("Found corpora files: %v", files)
+	return files
+}
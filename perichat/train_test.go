@@ -0,0 +1,82 @@
+package perichat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golangChatBot/bot/adapters/storage"
+)
+
+func writeCorpus(t *testing.T, file, question, answer string) {
+	t.Helper()
+
+	content := "categories:\n- test\nconversations:\n- - " + question + "\n  - " + answer + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkResponses fails unless question has exactly the given responses in the
+// model in storeFile.
+func checkResponses(t *testing.T, storeFile, question string, want map[string]int) {
+	t.Helper()
+
+	store, err := storage.NewSeparatedMemoryStorage(storeFile, Config{}.storageConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, _ := store.Find(question)
+	if len(responses) != len(want) {
+		t.Fatalf("the responses to %q are %v, want %v", question, responses, want)
+	}
+	for response, count := range want {
+		if responses[response] != count {
+			t.Fatalf("the responses to %q are %v, want %v", question, responses, want)
+		}
+	}
+}
+
+func TestTrainCorpora(t *testing.T) {
+	dir := t.TempDir()
+	storeFile := filepath.Join(dir, "model.gob")
+	first, second := filepath.Join(dir, "first.yml"), filepath.Join(dir, "second.yml")
+	writeCorpus(t, first, "What is the periMICA?", "An edge device.")
+	writeCorpus(t, second, "What is the periNODE?", "A sensor node.")
+
+	train := func(incremental bool, files ...string) {
+		t.Helper()
+		if err := trainCorpora(Config{}, storeFile, files, incremental, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a full training starts from scratch, even over an existing model
+	train(false, first)
+	train(false, first)
+	checkResponses(t, storeFile, "What is the periMICA?", map[string]int{"An edge device.": 1})
+
+	// an incremental one adds the new files only
+	train(true, first, second)
+	train(true, first, second)
+	checkResponses(t, storeFile, "What is the periMICA?", map[string]int{"An edge device.": 1})
+	checkResponses(t, storeFile, "What is the periNODE?", map[string]int{"A sensor node.": 1})
+
+	// a changed file replaces what it was trained with, the other files
+	// the model was trained with are kept
+	writeCorpus(t, first, "What is the periMICA?", "A gateway.")
+	train(true, first)
+	checkResponses(t, storeFile, "What is the periMICA?", map[string]int{"A gateway.": 1})
+	checkResponses(t, storeFile, "What is the periNODE?", map[string]int{"A sensor node.": 1})
+
+	// which can't be done without them
+	writeCorpus(t, first, "What is the periMICA?", "An edge gateway.")
+	if err := os.Remove(second); err != nil {
+		t.Fatal(err)
+	}
+	err := trainCorpora(Config{}, storeFile, []string{first}, true, false)
+	if err == nil || !strings.Contains(err.Error(), "second.yml") {
+		t.Fatalf("training without a file the model was trained with returned %v", err)
+	}
+}